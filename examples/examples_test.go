@@ -71,17 +71,57 @@ Output(2):  This is a dummy classification tag!`,
 			wantOut: `Output(1):  This is a dummy imgname!
 Output(2):  This is a dummy imgname!`,
 		},
+		{
+			name: "segment",
+			args: []string{filepath.Join(paths.imagesDir, "example.jpg")},
+			wantOut: `Output(1):  This is a dummy imgname!
+Output(2):  This is a dummy imgname!`,
+		},
+		{
+			name: "pose",
+			args: []string{filepath.Join(paths.imagesDir, "example.jpg")},
+			wantOut: `Output(1):  This is a dummy imgname!
+Output(2):  This is a dummy imgname!`,
+		},
+		{
+			name: "depth",
+			args: []string{filepath.Join(paths.imagesDir, "example.jpg")},
+			wantOut: `Output(1):  This is a dummy imgname!
+Output(2):  This is a dummy imgname!`,
+		},
+		{
+			name:    "minmax",
+			args:    []string{"262144", filepath.Join(paths.inputDir, "input_262144.csv"), "5", "100"},
+			wantOut: "Success!",
+		},
+		{
+			name:    "opencv",
+			args:    []string{filepath.Join(paths.imagesDir, "example.jpg")},
+			wantOut: "Success!",
+		},
 		{
 			name: "exec",
 			args: []string{filepath.Join(paths.libDir, "libmytestlib.so"), "10"},
 			wantOut: `Output(1):  10
 Output(2):  10
 Output(3):  10`,
+		},
+		{
+			name: "execlib",
+			args: []string{filepath.Join(paths.libDir, "libmytestlib.so"), "10"},
+			wantOut: `Output(1):  10
+Output(2):  10`,
 		},
 		{
 			name: "nonser",
 			args: []string{filepath.Join(paths.libDir, "libmytestlib.so")},
 			wantOut: `Input: 10 20 30 40 50 
+Output: 10 20 30 40 50 `,
+		},
+		{
+			name: "nonserargs",
+			args: []string{filepath.Join(paths.libDir, "libmytestlib.so")},
+			wantOut: `Input: 10 20 30 40 50 
 Output: 10 20 30 40 50 `,
 		},
 		{
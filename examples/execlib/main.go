@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/nubificus/vaccel-go/vaccel"
+)
+
+func main() {
+
+	/* Read User Args */
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: ./execlib <filename> <input>")
+		return
+	}
+
+	path := os.Args[1]
+	input := os.Args[2]
+	parsedInput, err := strconv.ParseInt(input, 10, 32)
+	if err != nil {
+		fmt.Println("error converting input")
+		return
+	}
+	inputInt32 := int32(parsedInput)
+
+	/* No resource is registered; the library is passed by path */
+	session, err := vaccel.NewSession()
+	if err != nil {
+		fmt.Println("error initializing session:", err)
+		os.Exit(1)
+	}
+	defer session.Close()
+
+	read, err := vaccel.NewArgArray(1)
+	if err != nil {
+		fmt.Println("Error creating the read arguments:", err)
+		return
+	}
+	defer read.Close()
+
+	write, err := vaccel.NewArgArray(1)
+	if err != nil {
+		fmt.Println("Error creating the write arguments:", err)
+		return
+	}
+	defer write.Close()
+
+	if err := vaccel.AddArg(read, inputInt32); err != nil {
+		fmt.Println("Error adding serialized arg:", err)
+		return
+	}
+
+	if err := vaccel.ExpectArg[int32](write); err != nil {
+		fmt.Println("Error defining expected arg:", err)
+		return
+	}
+
+	err = vaccel.ExecLibraryArgs(session, path, "mytestfunc", read, write)
+	if err != nil {
+		fmt.Println("An error occurred while running the operation:", err)
+		return
+	}
+
+	output, err := vaccel.ExtractArg[int32](write, 0)
+	if err != nil {
+		fmt.Println("Error extracting output:", err)
+		return
+	}
+	fmt.Println("Output(1): ", output)
+
+	/* Or */
+	output, err = vaccel.ExecLibraryTyped[int32, int32](context.Background(), session, path, "mytestfunc", inputInt32)
+	if err != nil {
+		fmt.Println("An error occurred while running the operation:", err)
+		return
+	}
+	fmt.Println("Output(2): ", output)
+}
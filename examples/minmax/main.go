@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nubificus/vaccel-go/vaccel"
+)
+
+func main() {
+
+	if len(os.Args) != 5 {
+		fmt.Printf("Usage: %s <num_elements> <input_file> <low_threshold> <high_threshold>\n", os.Args[0])
+		return
+	}
+
+	ndata, err := strconv.Atoi(os.Args[1])
+	if err != nil {
+		fmt.Printf("Invalid number of elements: %s\n", err)
+		os.Exit(1)
+	}
+	low, err := strconv.Atoi(os.Args[3])
+	if err != nil {
+		fmt.Printf("Invalid low threshold: %s\n", err)
+		os.Exit(1)
+	}
+	high, err := strconv.Atoi(os.Args[4])
+	if err != nil {
+		fmt.Printf("Invalid high threshold: %s\n", err)
+		os.Exit(1)
+	}
+
+	input, err := os.ReadFile(os.Args[2])
+	if err != nil {
+		fmt.Printf("Error reading file: %s\n", err)
+		os.Exit(1)
+	}
+
+	fields := strings.FieldsFunc(string(input), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	if len(fields) < ndata {
+		fmt.Printf("Input file has %d elements, want %d\n", len(fields), ndata)
+		os.Exit(1)
+	}
+
+	data := make([]float64, ndata)
+	for i := range data {
+		data[i], err = strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			fmt.Printf("Invalid element %d: %s\n", i, err)
+			os.Exit(1)
+		}
+	}
+
+	session, err := vaccel.NewSession()
+	if err != nil {
+		fmt.Println("error initializing session:", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			fmt.Println("An error occurred while freeing the session:", err)
+		}
+	}()
+
+	_, minV, maxV, err := vaccel.MinMax(session, data, ndata, low, high)
+	if err != nil {
+		fmt.Println("MinMax failed:", err)
+		return
+	}
+
+	fmt.Println("Success!")
+	fmt.Printf("min: %f max: %f\n", minV, maxV)
+}
@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+
+	"github.com/nubificus/vaccel-go/vaccel"
+)
+
+type MyData struct {
+	Size uint32
+	Arr  []uint32
+}
+
+func NewMyData(size uint32) MyData {
+	newMyData := MyData{Size: size, Arr: make([]uint32, size)}
+	fmt.Print("Input: ")
+	for i := 0; i < int(size); i++ {
+		newMyData.Arr[i] = 10 * uint32(i+1)
+		fmt.Print(newMyData.Arr[i], " ")
+	}
+	fmt.Println()
+	return newMyData
+}
+
+/* Serializes an instance of MyData as its size followed by its values */
+func (d MyData) MarshalBinary() ([]byte, error) {
+	buf := binary.NativeEndian.AppendUint32(nil, d.Size)
+	return binary.Append(buf, binary.NativeEndian, d.Arr)
+}
+
+/* Constructs an instance of MyData out of serialized data */
+func (d *MyData) UnmarshalBinary(data []byte) error {
+	n, err := binary.Decode(data, binary.NativeEndian, &d.Size)
+	if err != nil {
+		return err
+	}
+	d.Arr = make([]uint32, d.Size)
+	_, err = binary.Decode(data[n:], binary.NativeEndian, d.Arr)
+	return err
+}
+
+func main() {
+	/* Read User Args */
+	if len(os.Args) != 2 {
+		fmt.Printf("Usage: %s <shared-object>\n", os.Args[0])
+		return
+	}
+
+	if err := run(os.Args[1]); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+/* Runs the operation with ArgArrays, so the deferred cleanups run on errors */
+func run(path string) error {
+	var sharedObject vaccel.Resource
+	if err := sharedObject.InitErr(path, vaccel.ResourceLib); err != nil {
+		return fmt.Errorf("error creating shared object: %w", err)
+	}
+	defer sharedObject.Close()
+
+	session, err := vaccel.NewSession()
+	if err != nil {
+		return fmt.Errorf("error initializing session: %w", err)
+	}
+	defer session.Close()
+
+	if err := session.RegisterErr(&sharedObject); err != nil {
+		return fmt.Errorf("error registering resource with session: %w", err)
+	}
+
+	/* Create the argument arrays */
+	read, err := vaccel.NewArgArray(1)
+	if err != nil {
+		return fmt.Errorf("error creating the read arguments: %w", err)
+	}
+	defer read.Close()
+
+	write, err := vaccel.NewArgArray(1)
+	if err != nil {
+		return fmt.Errorf("error creating the write arguments: %w", err)
+	}
+	defer write.Close()
+
+	/* Add a non-serialized arg */
+	/* 10 20 30 40 50 */
+	var numEntries uint32 = 5
+	if err := vaccel.AddArg(read, NewMyData(numEntries)); err != nil {
+		return fmt.Errorf("error adding non-serialized arg: %w", err)
+	}
+
+	/* Define an expected argument */
+	var uint32Size uint32 = 4
+	expectedSize := int((numEntries + 1) * uint32Size)
+	if err := write.AddBytes(make([]byte, expectedSize)); err != nil {
+		return fmt.Errorf("error defining expected arg: %w", err)
+	}
+
+	/* Run the operation */
+	err = vaccel.ExecWithResourceArgs(session, &sharedObject, "mytestfunc_nonser", read, write)
+	if err != nil {
+		return fmt.Errorf("an error occurred while running the operation: %w", err)
+	}
+
+	/* Extract the Output */
+	mydataOut, err := vaccel.ExtractArg[MyData](write, 0)
+	if err != nil {
+		return fmt.Errorf("error extracting output: %w", err)
+	}
+
+	fmt.Print("Output: ")
+	for i := 0; i < int(mydataOut.Size); i++ {
+		fmt.Print(mydataOut.Arr[i], " ")
+	}
+	fmt.Println()
+
+	return nil
+}
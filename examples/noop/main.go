@@ -3,6 +3,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -10,24 +11,24 @@ import (
 )
 
 func main() {
-	var session vaccel.Session
-
-	err := session.Init(0)
-	if err != vaccel.OK {
-		fmt.Println("error initializing session")
-		os.Exit(int(err))
+	session, err := vaccel.NewSession()
+	if err != nil {
+		fmt.Println("error initializing session:", err)
+		os.Exit(1)
 	}
 
-	err = vaccel.NoOp(&session)
-	if err != vaccel.OK {
-		fmt.Println("An error occurred while running the operation")
-		os.Exit(err)
+	if err := vaccel.NoOpErr(session); err != nil {
+		fmt.Println("An error occurred while running the operation:", err)
+		var verr *vaccel.Error
+		if errors.As(err, &verr) {
+			os.Exit(verr.Code)
+		}
+		os.Exit(1)
 	}
 
-	err = session.Release()
-	if err != vaccel.OK {
-		fmt.Println("An error occurred while freeing the session")
-		os.Exit(err)
+	if err := session.Close(); err != nil {
+		fmt.Println("An error occurred while freeing the session:", err)
+		os.Exit(1)
 	}
 
 }
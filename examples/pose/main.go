@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nubificus/vaccel-go/vaccel"
+)
+
+func main() {
+
+	if len(os.Args) != 2 {
+		fmt.Printf("Usage: %s <filename>\n", os.Args[0])
+		return
+	}
+
+	image := filepath.Clean(os.Args[1])
+	imageBytes, err := os.ReadFile(image)
+	if err != nil {
+		fmt.Printf("Error reading file: %s\n", err)
+		os.Exit(1)
+	}
+
+	session, err := vaccel.NewSession()
+	if err != nil {
+		fmt.Println("error initializing session:", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			fmt.Println("An error occurred while freeing the session:", err)
+		}
+	}()
+
+	outImgName, err := vaccel.ImagePoseFromFile(session, image)
+	if err != nil {
+		fmt.Println("Image Pose Estimation failed:", err)
+		return
+	}
+	fmt.Println("Output(1): ", outImgName)
+
+	outImgName, err = vaccel.ImagePose(session, imageBytes)
+	if err != nil {
+		fmt.Println("Image Pose Estimation failed:", err)
+		return
+	}
+	fmt.Println("Output(2): ", outImgName)
+}
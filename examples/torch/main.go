@@ -13,7 +13,6 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
-	"unsafe"
 
 	"github.com/nubificus/vaccel-go/vaccel"
 	"golang.org/x/image/draw"
@@ -138,9 +137,8 @@ func main() {
 	var session vaccel.Session
 	var model vaccel.Resource
 	var runOptions vaccel.TorchBuffer
-	var inTensor vaccel.TorchTensor
+	var inTensor *vaccel.TorchTensor
 	var inTensors []vaccel.TorchTensor
-	var inputSize int
 	var inputData []float32
 	var stat error
 
@@ -162,31 +160,19 @@ func main() {
 		goto ReleaseSession
 	}
 
-	err = inTensor.Init([]int64{1, ImageChannels, ImageWidth, ImageHeight}, vaccel.TorchFloat)
-	if err != vaccel.OK {
-		fmt.Println("Could not create input tensor")
-		goto UnregisterResource
-	}
-
-	inputSize = int(unsafe.Sizeof(float32(0))) * int(ImageChannels*ImageWidth*ImageHeight)
-	if inputSize < 0 {
-		fmt.Println("inputSize must be non-negative")
-		goto ReleaseInTensor
-	}
-
 	inputData, stat = loadAndPreprocessImage(imageFile)
 	if stat != nil {
-		fmt.Fprintf(os.Stderr, "Could not load and preprocess image: %v\n", err)
-		goto ReleaseInTensor
+		fmt.Fprintf(os.Stderr, "Could not load and preprocess image: %v\n", stat)
+		goto UnregisterResource
 	}
 
-	err = inTensor.SetData(uintptr(unsafe.Pointer(&inputData[0])), uint(inputSize), false)
-	if err != vaccel.OK {
-		fmt.Println("Could not set tensor data")
-		goto ReleaseInTensor
+	inTensor, stat = vaccel.NewTorchTensorFrom(inputData, 1, ImageChannels, ImageWidth, ImageHeight)
+	if stat != nil {
+		fmt.Println("Could not create input tensor:", stat)
+		goto UnregisterResource
 	}
 
-	inTensors = []vaccel.TorchTensor{inTensor}
+	inTensors = []vaccel.TorchTensor{*inTensor}
 
 	err = vaccel.TorchModelLoad(&session, &model)
 	if err != vaccel.OK {
@@ -203,17 +189,15 @@ func main() {
 			goto ReleaseInTensor
 		}
 
-		outData, outLen := outTensors[0].TakeData()
-		if outData == 0 || outLen == 0 {
-			fmt.Println("Could not take data from out tensor")
+		output, stat := vaccel.AsSlice[float32](&outTensors[0])
+		if stat != nil || len(output) == 0 {
+			fmt.Println("Could not read data from out tensor")
 			break
 		}
 
-		output := unsafe.Slice((*float32)(unsafe.Pointer(outData)), 1000)
-
 		fmt.Println("Success!")
 
-		stat := processResult(output, labelsFile)
+		stat = processResult(output, labelsFile)
 		if stat != nil {
 			fmt.Println("Could not process result")
 			break
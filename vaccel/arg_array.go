@@ -0,0 +1,301 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+// #include <stdlib.h>
+// #include <vaccel/arg_array.h>
+import "C"
+import (
+	"encoding/binary"
+	"fmt"
+	"runtime"
+	"slices"
+	"unsafe"
+)
+
+// ArgArray is a list of operation arguments over the vaccel_arg_array C API.
+// It replaces ArgList. Added values are copied to C memory that is owned by
+// the array and freed when it is closed, so the Go values can be reused
+// right away. Output arguments are added like input ones, with placeholder
+// values of the size of the expected outputs, and are read back by index
+// after the operation.
+type ArgArray struct {
+	cArray *C.struct_vaccel_arg_array
+	mem    *argArrayMem
+	h      *handle
+}
+
+// argArrayMem is the C memory of the values added to an ArgArray.
+type argArrayMem struct {
+	bufs []unsafe.Pointer
+}
+
+// NewArgArray returns a new, empty ArgArray with room for capacity
+// arguments. The array grows as needed.
+func NewArgArray(capacity int) (*ArgArray, error) {
+	if capacity < 0 {
+		return nil, NewError("vaccel_arg_array_new", EINVAL)
+	}
+
+	a := &ArgArray{mem: &argArrayMem{}}
+	ret := int(C.vaccel_arg_array_new(&a.cArray, C.size_t(capacity)))
+	if ret != OK {
+		return nil, NewError("vaccel_arg_array_new", ret)
+	}
+
+	cArray, mem := a.cArray, a.mem
+	a.h = newHandle("ArgArray", func() int {
+		ret := int(C.vaccel_arg_array_delete(cArray))
+		if ret == OK {
+			for _, buf := range mem.bufs {
+				C.free(buf)
+			}
+			mem.bufs = nil
+		}
+		return ret
+	})
+
+	return a, nil
+}
+
+// Close deletes the array and frees the added values. It implements
+// io.Closer and is a no-op for a nil or closed array.
+func (a *ArgArray) Close() error {
+	if a == nil || a.h == nil || a.cArray == nil {
+		return nil
+	}
+	ret := a.h.close()
+	if ret == OK {
+		a.cArray = nil
+	}
+	return NewError("vaccel_arg_array_delete", ret)
+}
+
+// Len returns the number of arguments in the array.
+func (a *ArgArray) Len() int {
+	if a == nil || a.cArray == nil {
+		return 0
+	}
+	defer runtime.KeepAlive(a)
+	return int(C.vaccel_arg_array_count(a.cArray))
+}
+
+// cArgs returns the C arguments of the array and their number. A nil array
+// has no arguments. The caller must keep the array alive while it uses
+// them.
+func (a *ArgArray) cArgs() (*C.struct_vaccel_arg, int) {
+	if a == nil || a.cArray == nil {
+		return nil, 0
+	}
+	return a.cArray.args, int(a.cArray.count)
+}
+
+// cValue copies v to new C memory.
+func cValue[T any](v T) unsafe.Pointer {
+	p := C.malloc(C.size_t(unsafe.Sizeof(v)))
+	if p != nil {
+		*(*T)(p) = v
+	}
+	return p
+}
+
+// add adds the C memory p with add and, on success, hands it over to the
+// array. p is freed on failure.
+func (a *ArgArray) add(op string, p unsafe.Pointer, add func(p unsafe.Pointer) C.int) error {
+	if a == nil || a.cArray == nil {
+		C.free(p)
+		return NewError(op, EINVAL)
+	}
+	if p == nil {
+		return NewError(op, ENOMEM)
+	}
+
+	ret := int(add(p))
+	runtime.KeepAlive(a)
+	if ret != OK {
+		C.free(p)
+		return NewError(op, ret)
+	}
+	a.mem.bufs = append(a.mem.bufs, p)
+
+	return nil
+}
+
+// AddInt8 adds an int8 argument.
+func (a *ArgArray) AddInt8(v int8) error {
+	return a.add("vaccel_arg_array_add_int8", cValue(v), func(p unsafe.Pointer) C.int {
+		return C.vaccel_arg_array_add_int8(a.cArray, (*C.int8_t)(p))
+	})
+}
+
+// AddUint8 adds a uint8 argument.
+func (a *ArgArray) AddUint8(v uint8) error {
+	return a.add("vaccel_arg_array_add_uint8", cValue(v), func(p unsafe.Pointer) C.int {
+		return C.vaccel_arg_array_add_uint8(a.cArray, (*C.uint8_t)(p))
+	})
+}
+
+// AddInt16 adds an int16 argument.
+func (a *ArgArray) AddInt16(v int16) error {
+	return a.add("vaccel_arg_array_add_int16", cValue(v), func(p unsafe.Pointer) C.int {
+		return C.vaccel_arg_array_add_int16(a.cArray, (*C.int16_t)(p))
+	})
+}
+
+// AddUint16 adds a uint16 argument.
+func (a *ArgArray) AddUint16(v uint16) error {
+	return a.add("vaccel_arg_array_add_uint16", cValue(v), func(p unsafe.Pointer) C.int {
+		return C.vaccel_arg_array_add_uint16(a.cArray, (*C.uint16_t)(p))
+	})
+}
+
+// AddInt32 adds an int32 argument.
+func (a *ArgArray) AddInt32(v int32) error {
+	return a.add("vaccel_arg_array_add_int32", cValue(v), func(p unsafe.Pointer) C.int {
+		return C.vaccel_arg_array_add_int32(a.cArray, (*C.int32_t)(p))
+	})
+}
+
+// AddUint32 adds a uint32 argument.
+func (a *ArgArray) AddUint32(v uint32) error {
+	return a.add("vaccel_arg_array_add_uint32", cValue(v), func(p unsafe.Pointer) C.int {
+		return C.vaccel_arg_array_add_uint32(a.cArray, (*C.uint32_t)(p))
+	})
+}
+
+// AddInt64 adds an int64 argument.
+func (a *ArgArray) AddInt64(v int64) error {
+	return a.add("vaccel_arg_array_add_int64", cValue(v), func(p unsafe.Pointer) C.int {
+		return C.vaccel_arg_array_add_int64(a.cArray, (*C.int64_t)(p))
+	})
+}
+
+// AddUint64 adds a uint64 argument.
+func (a *ArgArray) AddUint64(v uint64) error {
+	return a.add("vaccel_arg_array_add_uint64", cValue(v), func(p unsafe.Pointer) C.int {
+		return C.vaccel_arg_array_add_uint64(a.cArray, (*C.uint64_t)(p))
+	})
+}
+
+// AddFloat32 adds a float32 argument.
+func (a *ArgArray) AddFloat32(v float32) error {
+	return a.add("vaccel_arg_array_add_float32", cValue(v), func(p unsafe.Pointer) C.int {
+		return C.vaccel_arg_array_add_float32(a.cArray, (*C.float)(p))
+	})
+}
+
+// AddFloat64 adds a float64 argument.
+func (a *ArgArray) AddFloat64(v float64) error {
+	return a.add("vaccel_arg_array_add_float64", cValue(v), func(p unsafe.Pointer) C.int {
+		return C.vaccel_arg_array_add_float64(a.cArray, (*C.double)(p))
+	})
+}
+
+// AddString adds a string argument, passed as a C string.
+func (a *ArgArray) AddString(s string) error {
+	return a.add("vaccel_arg_array_add_string", unsafe.Pointer(C.CString(s)), func(p unsafe.Pointer) C.int {
+		return C.vaccel_arg_array_add_string(a.cArray, (*C.char)(p))
+	})
+}
+
+// AddBytes adds a buffer argument with a copy of b. Add a zeroed b of the
+// expected size for an output buffer.
+func (a *ArgArray) AddBytes(b []byte) error {
+	return a.addBuffer("vaccel_arg_array_add_buffer", b, func(p unsafe.Pointer, size C.size_t) C.int {
+		return C.vaccel_arg_array_add_buffer(a.cArray, p, size)
+	})
+}
+
+// AddStruct adds a raw argument with v, which must be a fixed-size value,
+// like a struct of fixed-size fields, encoded in native byte order so that
+// it matches the respective C struct if it has no padding.
+func (a *ArgArray) AddStruct(v any) error {
+	if binary.Size(v) < 0 {
+		return &Error{Code: EINVAL, Op: "vaccel_arg_array_add_raw",
+			Msg: fmt.Sprintf("unsupported type %T", v)}
+	}
+	b, err := binary.Append(nil, binary.NativeEndian, v)
+	if err != nil {
+		return &Error{Code: EINVAL, Op: "vaccel_arg_array_add_raw", Msg: err.Error()}
+	}
+	return a.addBuffer("vaccel_arg_array_add_raw", b, func(p unsafe.Pointer, size C.size_t) C.int {
+		return C.vaccel_arg_array_add_raw(a.cArray, p, size)
+	})
+}
+
+func (a *ArgArray) addBuffer(op string, b []byte, add func(p unsafe.Pointer, size C.size_t) C.int) error {
+	/* allocate at least one byte so that an empty buffer is not NULL */
+	p := C.malloc(C.size_t(max(len(b), 1)))
+	if p != nil {
+		copy(unsafe.Slice((*byte)(p), len(b)), b)
+	}
+	return a.add(op, p, func(p unsafe.Pointer) C.int {
+		return add(p, C.size_t(len(b)))
+	})
+}
+
+// arg returns argument i of the array.
+func (a *ArgArray) arg(op string, i int) (*C.struct_vaccel_arg, error) {
+	args, n := a.cArgs()
+	if i < 0 || i >= n {
+		return nil, &Error{Code: EINVAL, Op: op,
+			Msg: fmt.Sprintf("argument %d out of range [0, %d)", i, n)}
+	}
+	return &unsafe.Slice(args, n)[i], nil
+}
+
+// Bytes returns a copy of the data of argument i.
+func (a *ArgArray) Bytes(i int) ([]byte, error) {
+	return a.argData("ArgArray.Bytes", i)
+}
+
+// StringArg returns argument i as a string, up to its first NUL byte.
+func (a *ArgArray) StringArg(i int) (string, error) {
+	b, err := a.Bytes(i)
+	if err != nil {
+		return "", err
+	}
+	for j, c := range b {
+		if c == 0 {
+			return string(b[:j]), nil
+		}
+	}
+	return string(b), nil
+}
+
+// Decode decodes argument i, in native byte order, into v, which must be a
+// pointer to, or a slice of, fixed-size values.
+func (a *ArgArray) Decode(i int, v any) error {
+	b, err := a.Bytes(i)
+	if err != nil {
+		return err
+	}
+	if _, err := binary.Decode(b, binary.NativeEndian, v); err != nil {
+		return &Error{Code: EINVAL, Op: "ArgArray.Decode",
+			Msg: fmt.Sprintf("argument %d: %v", i, err)}
+	}
+	return nil
+}
+
+func (a *ArgArray) addArg(op string, data []byte) error {
+	return a.addBuffer(op, data, func(p unsafe.Pointer, size C.size_t) C.int {
+		return C.vaccel_arg_array_add_buffer(a.cArray, p, size)
+	})
+}
+
+func (a *ArgArray) expectArg(op string, size int) error {
+	return a.addArg(op, make([]byte, size))
+}
+
+func (a *ArgArray) argData(op string, idx int) ([]byte, error) {
+	defer runtime.KeepAlive(a)
+
+	arg, err := a.arg(op, idx)
+	if err != nil {
+		return nil, err
+	}
+	if arg.buf == nil {
+		return nil, nil
+	}
+	return slices.Clone(unsafe.Slice((*byte)(arg.buf), int(arg.size))), nil
+}
@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestArgArray(t *testing.T) {
+	type pair struct {
+		A int32
+		B float32
+	}
+
+	a, err := NewArgArray(2)
+	if err != nil {
+		t.Skipf("can not create argument array: %v", err)
+	}
+	defer a.Close()
+	adds := []error{
+		a.AddInt8(-8),
+		a.AddUint8(8),
+		a.AddInt16(-16),
+		a.AddUint16(16),
+		a.AddInt32(-32),
+		a.AddUint32(32),
+		a.AddInt64(-64),
+		a.AddUint64(64),
+		a.AddFloat32(3.5),
+		a.AddFloat64(6.25),
+		a.AddString("hello"),
+		a.AddBytes([]byte{1, 2, 3}),
+		a.AddBytes(nil),
+		a.AddStruct(pair{A: 7, B: 0.5}),
+	}
+	if err := errors.Join(adds...); err != nil {
+		t.Fatal(err)
+	}
+	if a.Len() != len(adds) {
+		t.Fatalf("got %d arguments, want %d", a.Len(), len(adds))
+	}
+
+	var (
+		i8  int8
+		u8  uint8
+		i16 int16
+		u16 uint16
+		i32 int32
+		u32 uint32
+		i64 int64
+		u64 uint64
+		f32 float32
+		f64 float64
+		p   pair
+	)
+	for i, v := range []any{&i8, &u8, &i16, &u16, &i32, &u32, &i64, &u64, &f32, &f64} {
+		if err := a.Decode(i, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.Decode(13, &p); err != nil {
+		t.Fatal(err)
+	}
+	if i8 != -8 || u8 != 8 || i16 != -16 || u16 != 16 || i32 != -32 || u32 != 32 ||
+		i64 != -64 || u64 != 64 || f32 != 3.5 || f64 != 6.25 || p != (pair{7, 0.5}) {
+		t.Errorf("got %v %v %v %v %v %v %v %v %v %v %v", i8, u8, i16, u16, i32, u32, i64, u64, f32, f64, p)
+	}
+
+	if s, err := a.StringArg(10); err != nil || s != "hello" {
+		t.Errorf("StringArg(10) = %q, %v, want %q", s, err, "hello")
+	}
+	if b, err := a.Bytes(11); err != nil || !bytes.Equal(b, []byte{1, 2, 3}) {
+		t.Errorf("Bytes(11) = %v, %v, want [1 2 3]", b, err)
+	}
+	if b, err := a.Bytes(12); err != nil || len(b) != 0 {
+		t.Errorf("Bytes(12) = %v, %v, want empty", b, err)
+	}
+	if _, err := a.Bytes(len(adds)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Bytes out of range: got %v, want %v", err, ErrInvalid)
+	}
+	if err := a.AddStruct(map[int]int{}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("AddStruct of map: got %v, want %v", err, ErrInvalid)
+	}
+}
+
+func TestArgArrayClosed(t *testing.T) {
+	a, err := NewArgArray(1)
+	if err != nil {
+		t.Skipf("can not create argument array: %v", err)
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	if err := a.AddInt32(1); !errors.Is(err, ErrInvalid) {
+		t.Errorf("AddInt32 after Close: got %v, want %v", err, ErrInvalid)
+	}
+	if a.Len() != 0 {
+		t.Errorf("got %d arguments after Close, want 0", a.Len())
+	}
+}
+
+func TestGenopArgs(t *testing.T) {
+	sess := newTestSession(t)
+	read, err := NewArgArray(2)
+	if err != nil {
+		t.Skipf("can not create argument array: %v", err)
+	}
+	defer read.Close()
+
+	write, err := NewArgArray(2)
+	if err != nil {
+		t.Skipf("can not create argument array: %v", err)
+	}
+	defer write.Close()
+
+	if err := read.AddInt32(int32(OpNoop)); err != nil {
+		t.Fatal(err)
+	}
+	if err := write.AddBytes(make([]byte, 4)); err != nil {
+		t.Fatal(err)
+	}
+
+	err = GenopArgs(sess, read, write)
+	skipIfNotSupported(t, err)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := GenopArgs(nil, read, write); !errors.Is(err, ErrInvalid) {
+		t.Errorf("nil session: got %v, want %v", err, ErrInvalid)
+	}
+
+	out, err := write.Bytes(0)
+	if err != nil || !slices.Equal(out, make([]byte, 4)) {
+		t.Errorf("got %v, %v, want 4 zero bytes", out, err)
+	}
+}
@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// Args is an argument list that AddArg, ExpectArg and ExtractArg work with.
+// It is implemented by *ArgArray and *ArgList.
+type Args interface {
+	addArg(op string, data []byte) error
+	expectArg(op string, size int) error
+	argData(op string, idx int) ([]byte, error)
+}
+
+var (
+	_ Args = (*ArgArray)(nil)
+	_ Args = (*ArgList)(nil)
+)
+
+// AddArg encodes v and adds it to list. A v that implements
+// encoding.BinaryMarshaler is encoded with MarshalBinary, a []byte is added
+// as is, a string is added as a NUL-terminated C string, like
+// ArgArray.AddString does, and any other v must be a fixed-size value, like
+// an int32, a []float32 or a struct of such fields, and is encoded in native
+// byte order. The encoded data is copied to C memory owned by list.
+func AddArg[T any](list Args, v T) error {
+	if list == nil {
+		return NewError("AddArg", EINVAL)
+	}
+
+	data, err := marshalArg(v)
+	if err != nil {
+		return &Error{Code: EINVAL, Op: "AddArg", Msg: err.Error()}
+	}
+	return list.addArg("AddArg", data)
+}
+
+// ExpectArg adds an output argument of the encoded size of a T to list. T
+// must have a fixed size. Use ExpectNonSerialArg or ArgArray.AddBytes for
+// outputs of other sizes.
+func ExpectArg[T any](list Args) error {
+	if list == nil {
+		return NewError("ExpectArg", EINVAL)
+	}
+
+	var v T
+	size := binary.Size(v)
+	if size < 0 || reflect.TypeFor[T]().Kind() == reflect.Slice {
+		return &Error{Code: EINVAL, Op: "ExpectArg",
+			Msg: fmt.Sprintf("%T does not have a fixed size", v)}
+	}
+	return list.expectArg("ExpectArg", size)
+}
+
+// ExtractArg decodes argument idx of list into a new T, as encoded by
+// AddArg. A T, or a *T, that implements encoding.BinaryUnmarshaler is
+// decoded with UnmarshalBinary, and a slice of fixed-size values gets as
+// many elements as the argument holds, and a string ends at the first NUL
+// byte of the argument.
+func ExtractArg[T any](list Args, idx int) (T, error) {
+	var zero T
+	if list == nil {
+		return zero, NewError("ExtractArg", EINVAL)
+	}
+
+	data, err := list.argData("ExtractArg", idx)
+	if err != nil {
+		return zero, err
+	}
+
+	v, err := unmarshalArg[T](data)
+	if err != nil {
+		return zero, &Error{Code: EINVAL, Op: "ExtractArg",
+			Msg: fmt.Sprintf("argument %d: %v", idx, err)}
+	}
+	return v, nil
+}
+
+func marshalArg[T any](v T) ([]byte, error) {
+	return marshalValue(reflect.ValueOf(&v).Elem())
+}
+
+func unmarshalArg[T any](data []byte) (T, error) {
+	var v T
+	err := unmarshalValue(data, reflect.ValueOf(&v).Elem())
+	return v, err
+}
+
+// marshalValue encodes the addressable rv as documented in AddArg.
+func marshalValue(rv reflect.Value) ([]byte, error) {
+	if rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil, fmt.Errorf("nil %v", rv.Type())
+	}
+
+	switch v := rv.Interface().(type) {
+	case encoding.BinaryMarshaler:
+		return v.MarshalBinary()
+	case []byte:
+		return v, nil
+	case string:
+		return append([]byte(v), 0), nil
+	}
+	if m, ok := rv.Addr().Interface().(encoding.BinaryMarshaler); ok {
+		return m.MarshalBinary()
+	}
+
+	v := rv.Interface()
+	if binary.Size(v) < 0 {
+		return nil, fmt.Errorf("%v does not have a fixed size and does not implement encoding.BinaryMarshaler", rv.Type())
+	}
+	return binary.Append(nil, binary.NativeEndian, v)
+}
+
+// unmarshalValue decodes data into the addressable rv as documented in
+// ExtractArg, allocating rv if it is a nil pointer.
+func unmarshalValue(data []byte, rv reflect.Value) error {
+	typ := rv.Type()
+	if typ.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			rv.Set(reflect.New(typ.Elem()))
+		}
+		return unmarshalValue(data, rv.Elem())
+	}
+
+	if u, ok := rv.Addr().Interface().(encoding.BinaryUnmarshaler); ok {
+		return u.UnmarshalBinary(data)
+	}
+
+	switch {
+	case typ.Kind() == reflect.String:
+		s, _, _ := bytes.Cut(data, []byte{0})
+		rv.SetString(string(s))
+		return nil
+	case typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8:
+		rv.SetBytes(slices.Clone(data))
+		return nil
+	case typ.Kind() == reflect.Slice:
+		/* size the slice after data */
+		elemSize := binary.Size(reflect.Zero(typ.Elem()).Interface())
+		if elemSize <= 0 {
+			return fmt.Errorf("%v does not have fixed-size elements", typ)
+		}
+		if len(data)%elemSize != 0 {
+			return fmt.Errorf("%d bytes are not a multiple of the %d-byte elements of %v", len(data), elemSize, typ)
+		}
+		rv.Set(reflect.MakeSlice(typ, len(data)/elemSize, len(data)/elemSize))
+	}
+
+	if binary.Size(rv.Interface()) < 0 {
+		return fmt.Errorf("%v does not have a fixed size and does not implement encoding.BinaryUnmarshaler", typ)
+	}
+	_, err := binary.Decode(data, binary.NativeEndian, rv.Addr().Interface())
+	return err
+}
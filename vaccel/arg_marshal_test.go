@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"encoding/binary"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// sizedData is serialized as its size followed by its values, like the
+// MyData of examples/nonser.
+type sizedData struct {
+	Arr []uint32
+}
+
+func (d sizedData) MarshalBinary() ([]byte, error) {
+	buf := binary.NativeEndian.AppendUint32(nil, uint32(len(d.Arr)))
+	return binary.Append(buf, binary.NativeEndian, d.Arr)
+}
+
+func (d *sizedData) UnmarshalBinary(data []byte) error {
+	var size uint32
+	n, err := binary.Decode(data, binary.NativeEndian, &size)
+	if err != nil {
+		return err
+	}
+	d.Arr = make([]uint32, size)
+	_, err = binary.Decode(data[n:], binary.NativeEndian, d.Arr)
+	return err
+}
+
+type point struct {
+	X, Y int32
+}
+
+func testArgRoundTrip(t *testing.T, list Args) {
+	t.Helper()
+
+	data := sizedData{Arr: []uint32{10, 20, 30}}
+	adds := []error{
+		AddArg(list, int32(-5)),
+		AddArg(list, 2.5),
+		AddArg(list, point{1, 2}),
+		AddArg(list, []uint32{4, 5, 6}),
+		AddArg(list, []byte("raw")),
+		AddArg(list, "text"),
+		AddArg(list, data),
+		AddArg(list, &point{3, 4}),
+	}
+	if err := errors.Join(adds...); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(idx int, got, want any, err error) {
+		t.Helper()
+		if err != nil {
+			t.Errorf("argument %d: %v", idx, err)
+			return
+		}
+		switch want := want.(type) {
+		case []uint32:
+			if !slices.Equal(got.([]uint32), want) {
+				t.Errorf("argument %d: got %v, want %v", idx, got, want)
+			}
+		case []byte:
+			if string(got.([]byte)) != string(want) {
+				t.Errorf("argument %d: got %v, want %v", idx, got, want)
+			}
+		default:
+			if got != want {
+				t.Errorf("argument %d: got %v, want %v", idx, got, want)
+			}
+		}
+	}
+
+	i32, err := ExtractArg[int32](list, 0)
+	check(0, i32, int32(-5), err)
+	f64, err := ExtractArg[float64](list, 1)
+	check(1, f64, 2.5, err)
+	p, err := ExtractArg[point](list, 2)
+	check(2, p, point{1, 2}, err)
+	arr, err := ExtractArg[[]uint32](list, 3)
+	check(3, arr, []uint32{4, 5, 6}, err)
+	raw, err := ExtractArg[[]byte](list, 4)
+	check(4, raw, []byte("raw"), err)
+	s, err := ExtractArg[string](list, 5)
+	check(5, s, "text", err)
+	d, err := ExtractArg[sizedData](list, 6)
+	check(6, d.Arr, data.Arr, err)
+	dp, err := ExtractArg[*sizedData](list, 6)
+	check(6, dp.Arr, data.Arr, err)
+	pp, err := ExtractArg[*point](list, 7)
+	check(7, *pp, point{3, 4}, err)
+
+	if _, err := ExtractArg[int64](list, 0); !errors.Is(err, ErrInvalid) {
+		t.Errorf("ExtractArg of short argument: got %v, want %v", err, ErrInvalid)
+	}
+	if _, err := ExtractArg[int32](list, 100); !errors.Is(err, ErrInvalid) {
+		t.Errorf("ExtractArg out of range: got %v, want %v", err, ErrInvalid)
+	}
+}
+
+func TestArgMarshalArgArray(t *testing.T) {
+	a, err := NewArgArray(2)
+	if err != nil {
+		t.Skipf("can not create argument array: %v", err)
+	}
+	defer a.Close()
+
+	testArgRoundTrip(t, a)
+}
+
+func TestArgMarshalArgList(t *testing.T) {
+	list, err := ArgsInitErr(16)
+	if err != nil {
+		t.Skipf("can not create argument list: %v", err)
+	}
+	defer list.Close()
+
+	testArgRoundTrip(t, list)
+}
+
+func TestArgMarshalInvalid(t *testing.T) {
+	a, err := NewArgArray(2)
+	if err != nil {
+		t.Skipf("can not create argument array: %v", err)
+	}
+	defer a.Close()
+
+	if err := AddArg(a, map[string]int{}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("AddArg of map: got %v, want %v", err, ErrInvalid)
+	}
+	if err := AddArg(a, 1); !errors.Is(err, ErrInvalid) {
+		t.Errorf("AddArg of int: got %v, want %v", err, ErrInvalid)
+	}
+	if err := ExpectArg[[]uint32](a); !errors.Is(err, ErrInvalid) {
+		t.Errorf("ExpectArg of slice: got %v, want %v", err, ErrInvalid)
+	}
+
+	if err := ExpectArg[point](a); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := ExtractArg[point](a, 0); err != nil || p != (point{}) {
+		t.Errorf("ExtractArg of expected argument: got %v, %v", p, err)
+	}
+}
+
+func TestArgMarshalString(t *testing.T) {
+	a, err := NewArgArray(2)
+	if err != nil {
+		t.Skipf("can not create argument array: %v", err)
+	}
+	defer a.Close()
+
+	if err := a.AddString("text"); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddArg(a, "text"); err != nil {
+		t.Fatal(err)
+	}
+
+	/* both are passed as C strings */
+	for i := range 2 {
+		if got, err := a.Bytes(i); err != nil || string(got) != "text\x00" {
+			t.Errorf("argument %d: got %q, %v, want %q", i, got, err, "text\x00")
+		}
+	}
+}
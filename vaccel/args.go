@@ -6,14 +6,36 @@ package vaccel
 // #include <string.h>
 // #include <vaccel/arg.h>
 import "C"
-import "unsafe"
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"unsafe"
+)
 
 type Arg struct {
 	cArg *C.struct_vaccel_arg
+
+	// list the arguments belong to
+	list *ArgList
 }
 
 type ArgList struct {
 	cList *C.struct_vaccel_arg_list
+	h     *handle
+
+	// Go memory of the arguments, which C only references, so that it stays
+	// reachable as long as the list, or a copy of it, is
+	bufs []unsafe.Pointer
+}
+
+// cArgs returns the C arguments of the list and their number. A nil list
+// has no arguments. The caller must keep the list alive while it uses them.
+func (arglist *ArgList) cArgs() (*C.struct_vaccel_arg, int) {
+	if arglist == nil || arglist.cList == nil {
+		return nil, 0
+	}
+	return arglist.cList.list, int(arglist.cList.size)
 }
 
 /* Type of function to serialize a structure */
@@ -28,24 +50,33 @@ type Deserializer func(buf unsafe.Pointer) unsafe.Pointer
 func ArgsInit(size uint32) *ArgList {
 	list := new(ArgList)
 	list.cList = C.vaccel_args_init(C.uint(size))
+	if list.cList != nil {
+		cList := list.cList
+		list.h = newHandle("ArgList", func() int {
+			return int(C.vaccel_delete_args(cList))
+		})
+	}
 
 	return list
 }
 
 // Deprecated: The C vaccel_arg_list API is deprecated
 func (arglist *ArgList) AddSerialArg(buf unsafe.Pointer, size int) int {
-	return int(C.vaccel_add_serial_arg(arglist.cList, buf, C.uint(size)))
+	defer runtime.KeepAlive(arglist)
+	ret := int(C.vaccel_add_serial_arg(arglist.cList, buf, C.uint(size)))
+	if ret == OK {
+		arglist.bufs = append(arglist.bufs, buf)
+	}
+	return ret
 }
 
 // Deprecated: The C vaccel_arg_list API is deprecated
 func (arglist *ArgList) AddStringArg(arg string) int {
+	defer runtime.KeepAlive(arglist)
 	cStr := C.CString(arg)
 	ret := int(C.vaccel_add_serial_arg(arglist.cList, unsafe.Pointer(cStr), C.uint(C.strlen(cStr))))
 	if ret == OK {
-		length := int(arglist.cList.size)
-		idx := int(arglist.cList.curr_idx - 1)
-		slice := (*[1 << 16]C.int)(unsafe.Pointer(arglist.cList.idcs_allocated_space))[:length:length]
-		slice[idx] = 1
+		arglist.markAllocated()
 	} else {
 		C.free(unsafe.Pointer(cStr))
 	}
@@ -54,20 +85,26 @@ func (arglist *ArgList) AddStringArg(arg string) int {
 
 // Deprecated: The C vaccel_arg_list API is deprecated
 func (arglist *ArgList) AddInt32Arg(arg int32) int {
+	defer runtime.KeepAlive(arglist)
 	cInt := (*C.int32_t)(C.malloc(C.sizeof_int32_t))
 	*cInt = C.int32_t(arg)
 	ret := int(C.vaccel_add_serial_arg(arglist.cList, unsafe.Pointer(cInt), C.sizeof_int32_t))
 	if ret == OK {
-		length := int(arglist.cList.size)
-		idx := int(arglist.cList.curr_idx - 1)
-		slice := (*[1 << 16]C.int)(unsafe.Pointer(arglist.cList.idcs_allocated_space))[:length:length]
-		slice[idx] = 1
+		arglist.markAllocated()
 	} else {
 		C.free(unsafe.Pointer(cInt))
 	}
 	return ret
 }
 
+// markAllocated marks the last added argument as allocated by Go, so that
+// vaccel_delete_args frees it.
+func (arglist *ArgList) markAllocated() {
+	length := int(arglist.cList.size)
+	idx := int(arglist.cList.curr_idx - 1)
+	unsafe.Slice(arglist.cList.idcs_allocated_space, length)[idx] = 1
+}
+
 // Deprecated: The C vaccel_arg_list API is deprecated
 func (arglist *ArgList) AddNonSerialArg(nonSerialBuf unsafe.Pointer,
 	argtype uint32, serialize Serializer) int { //nolint:revive // argtype will be used in a next iteration
@@ -77,11 +114,17 @@ func (arglist *ArgList) AddNonSerialArg(nonSerialBuf unsafe.Pointer,
 
 // Deprecated: The C vaccel_arg_list API is deprecated
 func (arglist *ArgList) ExpectSerialArg(buf unsafe.Pointer, size int) int {
-	return int(C.vaccel_expect_serial_arg(arglist.cList, buf, C.uint(size)))
+	defer runtime.KeepAlive(arglist)
+	ret := int(C.vaccel_expect_serial_arg(arglist.cList, buf, C.uint(size)))
+	if ret == OK {
+		arglist.bufs = append(arglist.bufs, buf)
+	}
+	return ret
 }
 
 // Deprecated: The C vaccel_arg_list API is deprecated
 func (arglist *ArgList) ExpectNonSerialArg(expectedSize int) int {
+	defer runtime.KeepAlive(arglist)
 	return int(C.vaccel_expect_nonserial_arg(arglist.cList, C.uint(expectedSize)))
 }
 
@@ -89,16 +132,19 @@ func (arglist *ArgList) ExpectNonSerialArg(expectedSize int) int {
 func (arglist *ArgList) GetArgs() *Arg {
 	args := new(Arg)
 	args.cArg = arglist.cList.list
+	args.list = arglist
 	return args
 }
 
 // Deprecated: The C vaccel_arg_list API is deprecated
 func (args *Arg) ExtractSerialArg(idx int) unsafe.Pointer {
+	defer runtime.KeepAlive(args.list)
 	return C.vaccel_extract_serial_arg(args.cArg, C.int(idx))
 }
 
 // Deprecated: The C vaccel_arg_list API is deprecated
 func (arglist *ArgList) ExtractSerialArg(idx int) unsafe.Pointer {
+	defer runtime.KeepAlive(arglist)
 	return C.vaccel_extract_serial_arg(arglist.cList.list, C.int(idx))
 }
 
@@ -110,5 +156,134 @@ func (arglist *ArgList) ExtractNonSerialArg(idx int, deserialize Deserializer) u
 
 // Deprecated: The C vaccel_arg_list API is deprecated
 func (arglist *ArgList) Delete() int {
-	return int(C.vaccel_delete_args(arglist.cList))
+	if arglist.h == nil {
+		return EINVAL
+	}
+	ret := arglist.h.close()
+	if ret == OK {
+		arglist.cList = nil
+	}
+	return ret
+}
+
+// Close deletes the list. It implements io.Closer and, unlike Delete, it is a
+// no-op for a list that was never initialized.
+//
+// Deprecated: The C vaccel_arg_list API is deprecated
+func (arglist *ArgList) Close() error {
+	if arglist.h == nil {
+		return nil
+	}
+	return NewError("vaccel_delete_args", arglist.Delete())
+}
+
+// ArgsInitErr is like ArgsInit but returns an error.
+//
+// Deprecated: The C vaccel_arg_list API is deprecated
+func ArgsInitErr(size uint32) (*ArgList, error) {
+	list := ArgsInit(size)
+	if list.cList == nil {
+		return nil, NewError("vaccel_args_init", ENOMEM)
+	}
+	return list, nil
+}
+
+// AddSerialArgErr is like AddSerialArg but returns an error.
+//
+// Deprecated: The C vaccel_arg_list API is deprecated
+func (arglist *ArgList) AddSerialArgErr(buf unsafe.Pointer, size int) error {
+	return NewError("vaccel_add_serial_arg", arglist.AddSerialArg(buf, size))
+}
+
+// AddStringArgErr is like AddStringArg but returns an error.
+//
+// Deprecated: The C vaccel_arg_list API is deprecated
+func (arglist *ArgList) AddStringArgErr(arg string) error {
+	return NewError("vaccel_add_serial_arg", arglist.AddStringArg(arg))
+}
+
+// AddInt32ArgErr is like AddInt32Arg but returns an error.
+//
+// Deprecated: The C vaccel_arg_list API is deprecated
+func (arglist *ArgList) AddInt32ArgErr(arg int32) error {
+	return NewError("vaccel_add_serial_arg", arglist.AddInt32Arg(arg))
+}
+
+// AddNonSerialArgErr is like AddNonSerialArg but returns an error.
+//
+// Deprecated: The C vaccel_arg_list API is deprecated
+func (arglist *ArgList) AddNonSerialArgErr(nonSerialBuf unsafe.Pointer,
+	argtype uint32, serialize Serializer) error {
+	return NewError("vaccel_add_serial_arg", arglist.AddNonSerialArg(nonSerialBuf, argtype, serialize))
+}
+
+// ExpectSerialArgErr is like ExpectSerialArg but returns an error.
+//
+// Deprecated: The C vaccel_arg_list API is deprecated
+func (arglist *ArgList) ExpectSerialArgErr(buf unsafe.Pointer, size int) error {
+	return NewError("vaccel_expect_serial_arg", arglist.ExpectSerialArg(buf, size))
+}
+
+// ExpectNonSerialArgErr is like ExpectNonSerialArg but returns an error.
+//
+// Deprecated: The C vaccel_arg_list API is deprecated
+func (arglist *ArgList) ExpectNonSerialArgErr(expectedSize int) error {
+	return NewError("vaccel_expect_nonserial_arg", arglist.ExpectNonSerialArg(expectedSize))
+}
+
+// DeleteErr is like Delete but returns an error.
+//
+// Deprecated: The C vaccel_arg_list API is deprecated
+func (arglist *ArgList) DeleteErr() error {
+	return NewError("vaccel_delete_args", arglist.Delete())
+}
+
+func (arglist *ArgList) addArg(op string, data []byte) error {
+	if arglist == nil || arglist.cList == nil {
+		return NewError(op, EINVAL)
+	}
+
+	defer runtime.KeepAlive(arglist)
+
+	buf := C.malloc(C.size_t(max(len(data), 1)))
+	if buf == nil {
+		return NewError(op, ENOMEM)
+	}
+	copy(unsafe.Slice((*byte)(buf), len(data)), data)
+
+	ret := int(C.vaccel_add_serial_arg(arglist.cList, buf, C.uint(len(data))))
+	if ret != OK {
+		C.free(buf)
+		return NewError(op, ret)
+	}
+	arglist.markAllocated()
+
+	return nil
+}
+
+func (arglist *ArgList) expectArg(op string, size int) error {
+	if arglist == nil || arglist.cList == nil {
+		return NewError(op, EINVAL)
+	}
+	return NewError(op, arglist.ExpectNonSerialArg(size))
+}
+
+func (arglist *ArgList) argData(op string, idx int) ([]byte, error) {
+	if arglist == nil || arglist.cList == nil {
+		return nil, NewError(op, EINVAL)
+	}
+
+	defer runtime.KeepAlive(arglist)
+
+	n := int(arglist.cList.curr_idx)
+	if idx < 0 || idx >= n {
+		return nil, &Error{Code: EINVAL, Op: op,
+			Msg: fmt.Sprintf("argument %d out of range [0, %d)", idx, n)}
+	}
+
+	arg := unsafe.Slice(arglist.cList.list, n)[idx]
+	if arg.buf == nil {
+		return nil, nil
+	}
+	return slices.Clone(unsafe.Slice((*byte)(arg.buf), int(arg.size))), nil
 }
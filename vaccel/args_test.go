@@ -0,0 +1,37 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"unsafe"
+)
+
+func TestArgListKeepsBuffers(t *testing.T) {
+	list, err := ArgsInitErr(1)
+	if err != nil {
+		t.Skipf("can not create argument list: %v", err)
+	}
+	defer list.Close()
+
+	var collected atomic.Bool
+	buf := new([64]byte)
+	runtime.AddCleanup(buf, func(*atomic.Bool) { collected.Store(true) }, &collected)
+	if err := list.ExpectSerialArgErr(unsafe.Pointer(buf), len(buf)); err != nil {
+		t.Fatal(err)
+	}
+	buf = nil
+
+	/* a copy, like the one the Ctx variants run on, keeps the buffer */
+	cp, _ := copyArgList(list)
+	list = nil
+	for range 3 {
+		runtime.GC()
+	}
+	if collected.Load() {
+		t.Error("buffer of the list was collected while a copy of the list is reachable")
+	}
+	runtime.KeepAlive(cp)
+}
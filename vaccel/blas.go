@@ -0,0 +1,233 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+// #include <vaccel/ops/blas.h>
+import "C"
+import (
+	"fmt"
+	"math"
+	"runtime"
+)
+
+// Sgemm computes C = alpha*op(A)*op(B) + beta*C on row-major, single
+// precision matrices, where op(A) is m x k, op(B) is k x n and C is m x n.
+// op(X) is X, or its transpose if transX is set, in which case X is stored
+// as a k x m, or n x k, matrix. lda, ldb and ldc are the distances between
+// the rows of the stored matrices. vAccel does not transpose its operands,
+// so transposed operands are copied to new, non-transposed ones first.
+func Sgemm(sess *Session, transA, transB bool, m, n, k int, alpha float32,
+	a []float32, lda int, b []float32, ldb int, beta float32,
+	c []float32, ldc int) error {
+
+	if sess == nil || sess.cSess == nil {
+		return NewError("vaccel_sgemm", EINVAL)
+	}
+	if err := checkSgemm(transA, transB, m, n, k, a, lda, b, ldb, c, ldc); err != nil {
+		return err
+	}
+	if m == 0 || n == 0 {
+		return nil
+	}
+
+	if k == 0 {
+		/* op(A)*op(B) is a zero matrix */
+		for i := range m {
+			for j := range n {
+				c[i*ldc+j] *= beta
+			}
+		}
+		return nil
+	}
+
+	a, lda, b, ldb = sgemmOperands(transA, transB, m, n, k, a, lda, b, ldb)
+
+	ret := int(C.vaccel_sgemm(sess.cSess,
+		C.longlong(m), C.longlong(n), C.longlong(k),
+		C.float(alpha), (*C.float)(&a[0]), C.longlong(lda),
+		(*C.float)(&b[0]), C.longlong(ldb),
+		C.float(beta), (*C.float)(&c[0]), C.longlong(ldc)))
+	runtime.KeepAlive(sess)
+
+	return NewError("vaccel_sgemm", ret)
+}
+
+func checkSgemm(transA, transB bool, m, n, k int, a []float32, lda int,
+	b []float32, ldb int, c []float32, ldc int) error {
+
+	if m < 0 || n < 0 || k < 0 {
+		return &Error{Code: EINVAL, Op: "vaccel_sgemm",
+			Msg: fmt.Sprintf("invalid dimensions m=%d n=%d k=%d", m, n, k)}
+	}
+
+	aRows, aCols := m, k
+	if transA {
+		aRows, aCols = k, m
+	}
+	bRows, bCols := k, n
+	if transB {
+		bRows, bCols = n, k
+	}
+
+	if err := checkMatrix("A", a, aRows, aCols, lda); err != nil {
+		return err
+	}
+	if err := checkMatrix("B", b, bRows, bCols, ldb); err != nil {
+		return err
+	}
+	return checkMatrix("C", c, m, n, ldc)
+}
+
+// checkMatrix checks that data holds a rows x cols, row-major matrix with
+// rows ld elements apart.
+func checkMatrix(name string, data []float32, rows, cols, ld int) error {
+	if ld < max(1, cols) {
+		return &Error{Code: EINVAL, Op: "vaccel_sgemm",
+			Msg: fmt.Sprintf("ld%s=%d is less than the %d columns of %s", name, ld, cols, name)}
+	}
+	if rows == 0 || cols == 0 {
+		return nil
+	}
+	if rows-1 > (math.MaxInt-cols)/ld {
+		return &Error{Code: EINVAL, Op: "vaccel_sgemm",
+			Msg: fmt.Sprintf("%s is too large", name)}
+	}
+	if need := (rows-1)*ld + cols; len(data) < need {
+		return &Error{Code: EINVAL, Op: "vaccel_sgemm",
+			Msg: fmt.Sprintf("%s has %d elements, want at least %d", name, len(data), need)}
+	}
+	return nil
+}
+
+// sgemmOperands returns the operands of vaccel_sgemm for the checked
+// operands of Sgemm, transposing them if needed.
+func sgemmOperands(transA, transB bool, m, n, k int, a []float32, lda int,
+	b []float32, ldb int) ([]float32, int, []float32, int) {
+
+	if transA {
+		a, lda = transpose(a, k, m, lda), k
+	}
+	if transB {
+		b, ldb = transpose(b, n, k, ldb), n
+	}
+	return a, lda, b, ldb
+}
+
+// transpose returns the cols x rows, row-major transpose of the rows x
+// cols, row-major matrix in data with rows ld elements apart.
+func transpose(data []float32, rows, cols, ld int) []float32 {
+	t := make([]float32, rows*cols)
+	for i := range rows {
+		for j := range cols {
+			t[j*rows+i] = data[i*ld+j]
+		}
+	}
+	return t
+}
+
+// Layout is the memory layout of a Matrix.
+type Layout int
+
+const (
+	RowMajor Layout = iota
+	ColMajor
+)
+
+func (l Layout) String() string {
+	switch l {
+	case RowMajor:
+		return "row-major"
+	case ColMajor:
+		return "column-major"
+	default:
+		return fmt.Sprintf("Layout(%d)", int(l))
+	}
+}
+
+// Matrix is a dense, single precision matrix.
+type Matrix struct {
+	Rows   int
+	Cols   int
+	Data   []float32
+	Layout Layout
+}
+
+// NewMatrix returns a rows x cols, row-major matrix with data, or with
+// zeros if data is nil.
+func NewMatrix(rows, cols int, data []float32) *Matrix {
+	if data == nil {
+		data = make([]float32, rows*cols)
+	}
+	return &Matrix{Rows: rows, Cols: cols, Data: data, Layout: RowMajor}
+}
+
+// At returns the element of the matrix at row i and column j.
+func (mat *Matrix) At(i, j int) float32 {
+	return mat.Data[mat.index(i, j)]
+}
+
+// Set sets the element of the matrix at row i and column j to v.
+func (mat *Matrix) Set(i, j int, v float32) {
+	mat.Data[mat.index(i, j)] = v
+}
+
+func (mat *Matrix) index(i, j int) int {
+	if i < 0 || i >= mat.Rows || j < 0 || j >= mat.Cols {
+		panic(fmt.Sprintf("vaccel: matrix index (%d, %d) out of range [%d, %d]",
+			i, j, mat.Rows, mat.Cols))
+	}
+	if mat.Layout == ColMajor {
+		return j*mat.Rows + i
+	}
+	return i*mat.Cols + j
+}
+
+func (mat *Matrix) check(name string) error {
+	if mat == nil {
+		return &Error{Code: EINVAL, Op: "MatMul", Msg: name + " is nil"}
+	}
+	if mat.Layout != RowMajor && mat.Layout != ColMajor {
+		return &Error{Code: EINVAL, Op: "MatMul",
+			Msg: fmt.Sprintf("%s has invalid layout %v", name, mat.Layout)}
+	}
+	if mat.Rows < 0 || mat.Cols < 0 || len(mat.Data) != mat.Rows*mat.Cols {
+		return &Error{Code: EINVAL, Op: "MatMul",
+			Msg: fmt.Sprintf("%s has %d elements, want %d x %d", name, len(mat.Data), mat.Rows, mat.Cols)}
+	}
+	return nil
+}
+
+// matMulOperands returns the Sgemm operand of mat. A column-major matrix is
+// stored as the row-major matrix of its transpose, so it is passed
+// transposed.
+func matMulOperands(mat *Matrix) (bool, []float32, int) {
+	if mat.Layout == ColMajor {
+		return true, mat.Data, max(1, mat.Rows)
+	}
+	return false, mat.Data, max(1, mat.Cols)
+}
+
+// MatMul returns the row-major product of a and b, which can have any
+// layout.
+func MatMul(sess *Session, a, b *Matrix) (*Matrix, error) {
+	if err := a.check("A"); err != nil {
+		return nil, err
+	}
+	if err := b.check("B"); err != nil {
+		return nil, err
+	}
+	if a.Cols != b.Rows {
+		return nil, &Error{Code: EINVAL, Op: "MatMul",
+			Msg: fmt.Sprintf("can not multiply %d x %d by %d x %d matrices", a.Rows, a.Cols, b.Rows, b.Cols)}
+	}
+
+	c := NewMatrix(a.Rows, b.Cols, nil)
+	transA, aData, lda := matMulOperands(a)
+	transB, bData, ldb := matMulOperands(b)
+	err := Sgemm(sess, transA, transB, a.Rows, b.Cols, a.Cols,
+		1, aData, lda, bData, ldb, 0, c.Data, max(1, c.Cols))
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
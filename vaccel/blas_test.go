@@ -0,0 +1,185 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+// sgemmRef is a pure Go reference of Sgemm for non-transposed operands.
+func sgemmRef(m, n, k int, alpha float32, a []float32, lda int,
+	b []float32, ldb int, beta float32, c []float32, ldc int) {
+
+	for i := range m {
+		for j := range n {
+			var sum float32
+			for l := range k {
+				sum += a[i*lda+l] * b[l*ldb+j]
+			}
+			c[i*ldc+j] = alpha*sum + beta*c[i*ldc+j]
+		}
+	}
+}
+
+func equalFloat32s(a, b []float32) bool {
+	return slices.EqualFunc(a, b, func(x, y float32) bool {
+		return math.Abs(float64(x-y)) <= 1e-4*max(1, math.Abs(float64(y)))
+	})
+}
+
+func TestSgemmRef(t *testing.T) {
+	a := []float32{1, 2, 3, 4, 5, 6}    // 2 x 3
+	b := []float32{7, 8, 9, 10, 11, 12} // 3 x 2
+	c := []float32{1, 1, 1, 1}
+	sgemmRef(2, 2, 3, 1, a, 3, b, 2, 2, c, 2)
+
+	want := []float32{60, 66, 141, 156}
+	if !slices.Equal(c, want) {
+		t.Errorf("got %v, want %v", c, want)
+	}
+}
+
+func TestSgemmOperands(t *testing.T) {
+	a := []float32{1, 2, 3, 4, 5, 6}    // 2 x 3
+	b := []float32{7, 8, 9, 10, 11, 12} // 3 x 2
+	want := make([]float32, 4)
+	sgemmRef(2, 2, 3, 1, a, 3, b, 2, 0, want, 2)
+
+	aT := transpose(a, 2, 3, 3) // 3 x 2
+	bT := transpose(b, 3, 2, 2) // 2 x 3
+	/* padded rows */
+	bTPad := []float32{bT[0], bT[1], bT[2], -1, bT[3], bT[4], bT[5], -1}
+
+	tests := []struct {
+		name           string
+		transA, transB bool
+		a              []float32
+		lda            int
+		b              []float32
+		ldb            int
+	}{
+		{"NN", false, false, a, 3, b, 2},
+		{"TN", true, false, aT, 2, b, 2},
+		{"NT", false, true, a, 3, bT, 3},
+		{"TT", true, true, aT, 2, bTPad, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSgemm(tt.transA, tt.transB, 2, 2, 3, tt.a, tt.lda, tt.b, tt.ldb, make([]float32, 4), 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			a, lda, b, ldb := sgemmOperands(tt.transA, tt.transB, 2, 2, 3, tt.a, tt.lda, tt.b, tt.ldb)
+			c := make([]float32, 4)
+			sgemmRef(2, 2, 3, 1, a, lda, b, ldb, 0, c, 2)
+			if !slices.Equal(c, want) {
+				t.Errorf("got %v, want %v", c, want)
+			}
+		})
+	}
+}
+
+func TestSgemmInvalid(t *testing.T) {
+	sess := newTestSession(t)
+	a := make([]float32, 6)
+	b := make([]float32, 6)
+	c := make([]float32, 4)
+
+	tests := []struct {
+		name    string
+		m, n, k int
+		a       []float32
+		lda     int
+		b       []float32
+		ldb     int
+		c       []float32
+		ldc     int
+		transA  bool
+	}{
+		{name: "negative", m: -1, n: 2, k: 3, a: a, lda: 3, b: b, ldb: 2, c: c, ldc: 2},
+		{name: "short lda", m: 2, n: 2, k: 3, a: a, lda: 2, b: b, ldb: 2, c: c, ldc: 2},
+		{name: "short a", m: 2, n: 2, k: 3, a: a[:5], lda: 3, b: b, ldb: 2, c: c, ldc: 2},
+		{name: "short c", m: 2, n: 2, k: 3, a: a, lda: 3, b: b, ldb: 2, c: c[:3], ldc: 2},
+		{name: "transposed lda", m: 2, n: 2, k: 3, a: a, lda: 3, b: b, ldb: 2, c: c, ldc: 2, transA: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Sgemm(sess, tt.transA, false, tt.m, tt.n, tt.k, 1, tt.a, tt.lda, tt.b, tt.ldb, 0, tt.c, tt.ldc)
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("got %v, want %v", err, ErrInvalid)
+			}
+		})
+	}
+}
+
+func TestSgemm(t *testing.T) {
+	sess := newTestSession(t)
+
+	a := []float32{1, 2, 3, 4, 5, 6}    // 2 x 3
+	b := []float32{7, 8, 9, 10, 11, 12} // 3 x 2
+	c := []float32{1, 2, 3, 4}
+	want := slices.Clone(c)
+	sgemmRef(2, 2, 3, 2, a, 3, b, 2, 0.5, want, 2)
+
+	err := Sgemm(sess, false, false, 2, 2, 3, 2, a, 3, b, 2, 0.5, c, 2)
+	skipIfNotSupported(t, err)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalFloat32s(c, want) {
+		t.Errorf("got %v, want %v", c, want)
+	}
+}
+
+func TestMatMul(t *testing.T) {
+	sess := newTestSession(t)
+
+	a := NewMatrix(2, 3, []float32{1, 2, 3, 4, 5, 6})
+	b := NewMatrix(3, 2, []float32{7, 8, 9, 10, 11, 12})
+	want := []float32{58, 64, 139, 154}
+
+	/* same matrices, stored column-major */
+	aCol := &Matrix{Rows: 2, Cols: 3, Data: transpose(a.Data, 2, 3, 3), Layout: ColMajor}
+	bCol := &Matrix{Rows: 3, Cols: 2, Data: transpose(b.Data, 3, 2, 2), Layout: ColMajor}
+
+	tests := []struct {
+		name string
+		a, b *Matrix
+	}{
+		{"row-row", a, b},
+		{"col-row", aCol, b},
+		{"row-col", a, bCol},
+		{"col-col", aCol, bCol},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := range 2 {
+				for j := range 3 {
+					if tt.a.At(i, j) != a.At(i, j) {
+						t.Fatalf("A(%d, %d) = %v, want %v", i, j, tt.a.At(i, j), a.At(i, j))
+					}
+				}
+			}
+
+			c, err := MatMul(sess, tt.a, tt.b)
+			skipIfNotSupported(t, err)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Rows != 2 || c.Cols != 2 || c.Layout != RowMajor {
+				t.Fatalf("got %d x %d %v matrix, want 2 x 2 row-major", c.Rows, c.Cols, c.Layout)
+			}
+			if !equalFloat32s(c.Data, want) {
+				t.Errorf("got %v, want %v", c.Data, want)
+			}
+		})
+	}
+
+	if _, err := MatMul(sess, a, a); !errors.Is(err, ErrInvalid) {
+		t.Errorf("MatMul of 2 x 3 by 2 x 3: got %v, want %v", err, ErrInvalid)
+	}
+}
@@ -4,7 +4,13 @@ package vaccel
 
 // #include <vaccel/blob.h>
 import "C"
-import "unsafe"
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"unsafe"
+)
 
 type BlobType int32
 
@@ -14,12 +20,45 @@ const (
 	BlobMapped BlobType = C.VACCEL_BLOB_MAPPED
 )
 
+func (t BlobType) String() string {
+	switch t {
+	case BlobFile:
+		return "file"
+	case BlobBuffer:
+		return "buffer"
+	case BlobMapped:
+		return "mapped"
+	default:
+		return fmt.Sprintf("BlobType(%d)", int32(t))
+	}
+}
+
 type Blob struct {
 	cBlob *C.struct_vaccel_blob
+	h     *handle
+	// resource that owns the blob, for blobs returned by Resource.Blobs
+	res *Resource
+	// whether the data is a mapping of a file, which vAccel sees as a buffer
+	mapped bool
+}
+
+// valid reports whether the C blob can be accessed, i.e. it is initialized
+// and, if it is owned by a resource, the resource is not released.
+func (b *Blob) valid() bool {
+	return b != nil && b.cBlob != nil && (b.res == nil || b.res.cRes != nil)
+}
+
+// keepAlive keeps b, and the resource that owns it, alive until it is
+// called.
+func (b *Blob) keepAlive() {
+	if b != nil {
+		runtime.KeepAlive(b.res)
+	}
+	runtime.KeepAlive(b)
 }
 
 func (b *Blob) Init(path string) int {
-	return int(C.vaccel_blob_new(&b.cBlob, C.CString(path)))
+	return b.track(int(C.vaccel_blob_new(&b.cBlob, C.CString(path))))
 }
 
 func (b *Blob) InitFromBuf(bytes []byte, own bool, filename string, dir string, randomize bool) int {
@@ -35,9 +74,118 @@ func (b *Blob) InitFromBuf(bytes []byte, own bool, filename string, dir string,
 		defer C.free(unsafe.Pointer(cdname))
 	}
 
-	return int(C.vaccel_blob_from_buf(&b.cBlob, cBlobBytes, cBlobLen, C.bool(own), C.CString(filename), cdname, C.bool(randomize)))
+	return b.track(int(C.vaccel_blob_from_buf(&b.cBlob, cBlobBytes, cBlobLen, C.bool(own), C.CString(filename), cdname, C.bool(randomize))))
+}
+
+// track attaches a handle to a newly created C blob so it is released
+// exactly once.
+func (b *Blob) track(ret int) int {
+	if ret != OK {
+		return ret
+	}
+
+	cBlob := b.cBlob
+	b.h = newHandle("Blob", func() int {
+		return int(C.vaccel_blob_delete(cBlob))
+	})
+
+	return OK
 }
 
 func (b *Blob) Release() int {
-	return int(C.vaccel_blob_delete(b.cBlob))
+	if b.h == nil {
+		return EINVAL
+	}
+	ret := b.h.close()
+	if ret == OK {
+		b.cBlob = nil
+	}
+	return ret
+}
+
+// Close releases the blob. It implements io.Closer and, unlike Release, it
+// is a no-op for a blob that was never initialized.
+func (b *Blob) Close() error {
+	if b.h == nil {
+		return nil
+	}
+	return NewError("vaccel_blob_delete", b.Release())
+}
+
+// InitErr is like Init but returns an error.
+func (b *Blob) InitErr(path string) error {
+	return NewError("vaccel_blob_new", b.Init(path))
+}
+
+// InitFromBufErr is like InitFromBuf but returns an error.
+func (b *Blob) InitFromBufErr(bytes []byte, own bool, filename string, dir string, randomize bool) error {
+	if len(bytes) == 0 {
+		return NewError("vaccel_blob_from_buf", EINVAL)
+	}
+	return NewError("vaccel_blob_from_buf", b.InitFromBuf(bytes, own, filename, dir, randomize))
+}
+
+// ReleaseErr is like Release but returns an error.
+func (b *Blob) ReleaseErr() error {
+	return NewError("vaccel_blob_delete", b.Release())
+}
+
+// Name returns the name of the blob.
+func (b *Blob) Name() string {
+	defer b.keepAlive()
+	if !b.valid() || b.cBlob.name == nil {
+		return ""
+	}
+	return C.GoString(b.cBlob.name)
+}
+
+// Path returns the path of the file backing the blob, or an empty string for
+// blobs that only live in memory.
+func (b *Blob) Path() string {
+	defer b.keepAlive()
+	if !b.valid() || b.cBlob.path == nil {
+		return ""
+	}
+	return C.GoString(b.cBlob.path)
+}
+
+// Size returns the size of the blob data in bytes.
+func (b *Blob) Size() int {
+	defer b.keepAlive()
+	if !b.valid() {
+		return 0
+	}
+	return int(b.cBlob.size)
+}
+
+// Type returns the type of the blob.
+func (b *Blob) Type() BlobType {
+	defer b.keepAlive()
+	if !b.valid() {
+		return -1
+	}
+	if b.mapped {
+		return BlobMapped
+	}
+	return BlobType(b.cBlob._type)
+}
+
+// Bytes returns a copy of the blob data. The data of file blobs that are not
+// loaded in memory is read from their file.
+func (b *Blob) Bytes() ([]byte, error) {
+	defer b.keepAlive()
+	if !b.valid() {
+		return nil, NewError("Blob.Bytes", EINVAL)
+	}
+
+	if b.cBlob.data != nil {
+		data := unsafe.Slice((*byte)(unsafe.Pointer(b.cBlob.data)), int(b.cBlob.size))
+		return slices.Clone(data), nil
+	}
+
+	p := b.Path()
+	if p == "" {
+		return nil, &Error{Code: ENOENT, Op: "Blob.Bytes", Msg: "blob has no data or file"}
+	}
+	return os.ReadFile(p)
 }
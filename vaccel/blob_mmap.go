@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+// #include <vaccel/blob.h>
+import "C"
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// NewMappedBlob creates a blob from the file at path by mapping it read-only
+// in memory. The mapping is handed to vAccel as a buffer blob it does not
+// own, so the file is neither copied nor loaded in memory before it is used.
+// Type reports the blob as BlobMapped, although vAccel sees a BlobBuffer.
+// The file is unmapped when the blob is released.
+func NewMappedBlob(path string) (*Blob, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !fi.Mode().IsRegular() || fi.Size() == 0 {
+		return nil, &Error{Code: EINVAL, Op: "NewMappedBlob", Msg: path + " is not a non-empty regular file"}
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(fi.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+
+	cName := C.CString(filepath.Base(path))
+	defer C.free(unsafe.Pointer(cName))
+
+	var cBlob *C.struct_vaccel_blob
+	ret := int(C.vaccel_blob_from_buf(&cBlob, (*C.uchar)(&data[0]), C.size_t(len(data)),
+		false, cName, nil, false))
+	if ret != OK {
+		syscall.Munmap(data)
+		return nil, NewError("vaccel_blob_from_buf", ret)
+	}
+
+	b := &Blob{cBlob: cBlob, mapped: true}
+	b.h = newHandle("Blob", func() int {
+		ret := int(C.vaccel_blob_delete(cBlob))
+		if ret == OK {
+			syscall.Munmap(data)
+		}
+		return ret
+	})
+
+	return b, nil
+}
+
+// NewResourceFromMmap creates a resource of type typ from the files at
+// paths, mapping each of them in memory like NewMappedBlob does, so its
+// Blobs are reported as BlobMapped. The files are unmapped when the resource
+// is released.
+func NewResourceFromMmap(typ ResourceType, paths ...string) (*Resource, error) {
+	if len(paths) == 0 {
+		return nil, NewError("NewResourceFromMmap", EINVAL)
+	}
+
+	blobs := make([]*Blob, 0, len(paths))
+	for _, p := range paths {
+		b, err := NewMappedBlob(p)
+		if err != nil {
+			releaseBlobs(blobs)
+			return nil, err
+		}
+		blobs = append(blobs, b)
+	}
+
+	r, err := newResourceFromOwnedBlobs(blobs, typ)
+	if err != nil {
+		return nil, err
+	}
+	r.mapped = true
+	return r, nil
+}
@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const benchBlobSize = 64 << 20
+
+func newTestBlobFile(t *testing.T, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "model.bin")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// mapped reports whether path is mapped in the memory of the process.
+func mapped(t *testing.T, path string) bool {
+	t.Helper()
+
+	maps, err := os.ReadFile("/proc/self/maps")
+	if err != nil {
+		t.Skipf("cannot read mappings: %v", err)
+	}
+	return bytes.Contains(maps, []byte(path))
+}
+
+func TestNewMappedBlob(t *testing.T) {
+	data := bytes.Repeat([]byte("vaccel"), 1000)
+	path := newTestBlobFile(t, data)
+
+	b, err := NewMappedBlob(path)
+	if err != nil {
+		t.Fatalf("NewMappedBlob: %v", err)
+	}
+	if !mapped(t, path) {
+		t.Errorf("%s is not mapped", path)
+	}
+
+	if b.Type() != BlobMapped {
+		t.Errorf("Type = %v, want %v", b.Type(), BlobMapped)
+	}
+	if b.Name() != "model.bin" || b.Size() != len(data) {
+		t.Errorf("blob is %q of size %d, want %q of size %d", b.Name(), b.Size(), "model.bin", len(data))
+	}
+	if got, err := b.Bytes(); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("Bytes = %d bytes, %v, want the %d bytes of the file", len(got), err, len(data))
+	}
+
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if mapped(t, path) {
+		t.Errorf("%s is still mapped after Close", path)
+	}
+}
+
+func TestNewResourceFromMmap(t *testing.T) {
+	data := bytes.Repeat([]byte("vaccel"), 1000)
+	path := newTestBlobFile(t, data)
+
+	r, err := NewResourceFromMmap(ResourceData, path)
+	if err != nil {
+		t.Fatalf("NewResourceFromMmap: %v", err)
+	}
+	defer r.Close()
+
+	blobs := r.Blobs()
+	if len(blobs) != 1 {
+		t.Fatalf("got %d blobs, want 1", len(blobs))
+	}
+	if blobs[0].Type() != BlobMapped {
+		t.Errorf("Type = %v, want %v", blobs[0].Type(), BlobMapped)
+	}
+}
+
+func TestNewMappedBlobInvalid(t *testing.T) {
+	dir := t.TempDir()
+	empty := newTestBlobFile(t, nil)
+
+	for _, path := range []string{dir, empty} {
+		if _, err := NewMappedBlob(path); !errors.Is(err, ErrInvalid) {
+			t.Errorf("NewMappedBlob(%q) = %v, want ErrInvalid", path, err)
+		}
+	}
+	if _, err := NewMappedBlob(filepath.Join(dir, "missing")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("NewMappedBlob of a missing file = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func benchBlobFile(b *testing.B) string {
+	b.Helper()
+
+	path := filepath.Join(b.TempDir(), "model.bin")
+	data := make([]byte, benchBlobSize)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		b.Fatal(err)
+	}
+
+	return path
+}
+
+// rss returns the resident set size of the process in bytes.
+func rss(b *testing.B) int64 {
+	b.Helper()
+
+	statm, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		b.Skipf("cannot read RSS: %v", err)
+	}
+	fields := strings.Fields(string(statm))
+	pages, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return pages * int64(os.Getpagesize())
+}
+
+func BenchmarkBlobInitFromBuf(b *testing.B) {
+	path := benchBlobFile(b)
+	b.ReportAllocs()
+
+	var rssDelta int64
+	for b.Loop() {
+		before := rss(b)
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		var blob Blob
+		if err := blob.InitFromBufErr(data, true, "model.bin", "", false); err != nil {
+			b.Fatal(err)
+		}
+
+		rssDelta += rss(b) - before
+		if err := blob.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ReportMetric(float64(rssDelta)/float64(b.N), "rss-B/op")
+}
+
+func BenchmarkNewMappedBlob(b *testing.B) {
+	path := benchBlobFile(b)
+	b.ReportAllocs()
+
+	var rssDelta int64
+	for b.Loop() {
+		before := rss(b)
+
+		blob, err := NewMappedBlob(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		rssDelta += rss(b) - before
+		if err := blob.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ReportMetric(float64(rssDelta)/float64(b.N), "rss-B/op")
+}
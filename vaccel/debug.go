@@ -0,0 +1,9 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !vacceldebug
+
+package vaccel
+
+// leakDebug enables reporting of C-backed values that were garbage collected
+// without being closed. Build with -tags vacceldebug to enable it.
+const leakDebug = false
@@ -4,6 +4,10 @@ package vaccel
 
 // #include <errno.h>
 import "C"
+import (
+	"fmt"
+	"syscall"
+)
 
 const (
 	OK           int = 0              // All Good :D
@@ -33,3 +37,110 @@ const (
 	EREMOTEIO    int = C.EREMOTEIO    // Remote I/O error
 	EFAULT       int = C.EFAULT       // Bad address
 )
+
+var errorMessages = map[int]string{
+	EINVAL:       "invalid argument",
+	ENOMEM:       "out of memory",
+	ENOTSUP:      "operation not supported",
+	EINPROGRESS:  "operation now in progress",
+	EBUSY:        "device or resource busy",
+	EEXIST:       "file exists",
+	ENOENT:       "no such file or directory",
+	ELIBBAD:      "corrupted shared library",
+	ENODEV:       "no such device",
+	EIO:          "I/O error",
+	ESESS:        "session error",
+	EBACKEND:     "backend error",
+	ENOEXEC:      "exec format error",
+	ENAMETOOLONG: "file name too long",
+	EUSERS:       "too many users",
+	EPERM:        "operation not permitted",
+	ELOOP:        "too many symbolic links",
+	EMLINK:       "too many links",
+	ENOSPC:       "no space left on device",
+	ENOTDIR:      "not a directory",
+	EROFS:        "read-only file system",
+	EACCES:       "permission denied",
+	EBADF:        "bad file number",
+	EREMOTEIO:    "remote I/O error",
+	EFAULT:       "bad address",
+}
+
+// Error is the error returned by the error-returning variants of the vaccel
+// API. It records the vAccel error code, the operation that failed and a
+// human readable message.
+type Error struct {
+	Code int
+	Op   string
+	Msg  string
+}
+
+// Sentinel errors for each vAccel error code. Any *Error with the same code
+// matches them with errors.Is.
+var (
+	ErrInvalid      = &Error{Code: EINVAL, Msg: errorMessages[EINVAL]}
+	ErrNoMem        = &Error{Code: ENOMEM, Msg: errorMessages[ENOMEM]}
+	ErrNotSupported = &Error{Code: ENOTSUP, Msg: errorMessages[ENOTSUP]}
+	ErrInProgress   = &Error{Code: EINPROGRESS, Msg: errorMessages[EINPROGRESS]}
+	ErrBusy         = &Error{Code: EBUSY, Msg: errorMessages[EBUSY]}
+	ErrExist        = &Error{Code: EEXIST, Msg: errorMessages[EEXIST]}
+	ErrNotExist     = &Error{Code: ENOENT, Msg: errorMessages[ENOENT]}
+	ErrLibBad       = &Error{Code: ELIBBAD, Msg: errorMessages[ELIBBAD]}
+	ErrNoDev        = &Error{Code: ENODEV, Msg: errorMessages[ENODEV]}
+	ErrIO           = &Error{Code: EIO, Msg: errorMessages[EIO]}
+	ErrSession      = &Error{Code: ESESS, Msg: errorMessages[ESESS]}
+	ErrBackend      = &Error{Code: EBACKEND, Msg: errorMessages[EBACKEND]}
+	ErrNoExec       = &Error{Code: ENOEXEC, Msg: errorMessages[ENOEXEC]}
+	ErrNameTooLong  = &Error{Code: ENAMETOOLONG, Msg: errorMessages[ENAMETOOLONG]}
+	ErrUsers        = &Error{Code: EUSERS, Msg: errorMessages[EUSERS]}
+	ErrPermission   = &Error{Code: EPERM, Msg: errorMessages[EPERM]}
+	ErrLoop         = &Error{Code: ELOOP, Msg: errorMessages[ELOOP]}
+	ErrMLink        = &Error{Code: EMLINK, Msg: errorMessages[EMLINK]}
+	ErrNoSpace      = &Error{Code: ENOSPC, Msg: errorMessages[ENOSPC]}
+	ErrNotDir       = &Error{Code: ENOTDIR, Msg: errorMessages[ENOTDIR]}
+	ErrReadOnly     = &Error{Code: EROFS, Msg: errorMessages[EROFS]}
+	ErrAccess       = &Error{Code: EACCES, Msg: errorMessages[EACCES]}
+	ErrBadFile      = &Error{Code: EBADF, Msg: errorMessages[EBADF]}
+	ErrRemoteIO     = &Error{Code: EREMOTEIO, Msg: errorMessages[EREMOTEIO]}
+	ErrFault        = &Error{Code: EFAULT, Msg: errorMessages[EFAULT]}
+)
+
+func (e *Error) Error() string {
+	msg := e.Msg
+	if msg == "" {
+		msg = fmt.Sprintf("error code %d", e.Code)
+	}
+	if e.Op == "" {
+		return "vaccel: " + msg
+	}
+	return "vaccel: " + e.Op + ": " + msg
+}
+
+// Is reports whether target is an *Error with the same code. An *Error
+// target that sets Op only matches errors of that operation.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return t.Code == e.Code && (t.Op == "" || t.Op == e.Op)
+}
+
+// Unwrap returns the underlying errno so errors.Is(err, syscall.EINVAL) and
+// similar checks work as well.
+func (e *Error) Unwrap() error {
+	return syscall.Errno(e.Code)
+}
+
+// NewError converts a vAccel return code into an error. It returns nil for
+// OK.
+func NewError(op string, code int) error {
+	if code == OK {
+		return nil
+	}
+	msg, ok := errorMessages[code]
+	if !ok {
+		msg = fmt.Sprintf("error code %d", code)
+	}
+	return &Error{Code: code, Op: op, Msg: msg}
+}
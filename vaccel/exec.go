@@ -4,9 +4,17 @@ package vaccel
 
 // #include <vaccel/ops/exec.h>
 import "C"
+import (
+	"context"
+	"runtime"
+	"unsafe"
+)
 
 func ExecWithResource(sess *Session, res *Resource, funcname string,
 	read *ArgList, write *ArgList) int {
+	if sess.Poisoned() {
+		return EBUSY
+	}
 	cfunc := C.CString(funcname)
 	cread := read.cList.list
 	cwrite := write.cList.list
@@ -15,6 +23,199 @@ func ExecWithResource(sess *Session, res *Resource, funcname string,
 	cNrWrite := C.size_t(write.cList.size)
 
 	cRet := C.vaccel_exec_with_resource(sess.cSess, res.cRes, cfunc, cread, cNrRead, cwrite, cNrWrite)
+	runtime.KeepAlive(sess)
+	runtime.KeepAlive(res)
+	runtime.KeepAlive(read)
+	runtime.KeepAlive(write)
 	return int(cRet)
 
 }
+
+type execOptions struct {
+	checkSymbol bool
+}
+
+// ExecOption configures an exec operation.
+type ExecOption func(*execOptions)
+
+// WithSymbolCheck checks that the shared object of the operation exports
+// the function before running it. A missing function is reported with an
+// ErrNotExist error that suggests the closest exported names, instead of a
+// plugin error.
+func WithSymbolCheck() ExecOption {
+	return func(o *execOptions) {
+		o.checkSymbol = true
+	}
+}
+
+// checkExec runs the checks opts ask for before an exec operation on res.
+func checkExec(op string, res *Resource, funcname string, opts []ExecOption) error {
+	var o execOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.checkSymbol {
+		if res == nil {
+			return NewError(op, EINVAL)
+		}
+		return checkSymbol(op, res, funcname)
+	}
+	return nil
+}
+
+// ExecWithResourceErr is like ExecWithResource but returns an error.
+func ExecWithResourceErr(sess *Session, res *Resource, funcname string,
+	read *ArgList, write *ArgList, opts ...ExecOption) error {
+	if err := checkExec("vaccel_exec_with_resource", res, funcname, opts); err != nil {
+		return err
+	}
+	return NewError("vaccel_exec_with_resource", ExecWithResource(sess, res, funcname, read, write))
+}
+
+// ExecWithResourceCtx is like ExecWithResourceErr but returns ctx.Err() as
+// soon as ctx is done. The C call can not be interrupted, so it keeps
+// running in the background and sess is poisoned until it returns. res,
+// read and write can be released right away; their C memory is released
+// when the call returns. The Go buffers added to read and write with
+// AddSerialArg or ExpectSerialArg are kept reachable until then too, but
+// the call may still write to them after ExecWithResourceCtx returns.
+func ExecWithResourceCtx(ctx context.Context, sess *Session, res *Resource, funcname string,
+	read *ArgList, write *ArgList, opts ...ExecOption) error {
+	if err := checkExec("vaccel_exec_with_resource", res, funcname, opts); err != nil {
+		return err
+	}
+	if res == nil || read == nil || write == nil {
+		return NewError("vaccel_exec_with_resource", EINVAL)
+	}
+
+	r, rd, wr := res.ref(), *read, *write
+	_, err := sess.callCtx(ctx, "vaccel_exec_with_resource", func() int {
+		return ExecWithResource(sess, r, funcname, &rd, &wr)
+	}, nil, r.h, rd.h, wr.h)
+	return err
+}
+
+// ExecWithResourceArgs is like ExecWithResourceErr but takes its arguments
+// as ArgArrays. A nil array has no arguments.
+func ExecWithResourceArgs(sess *Session, res *Resource, funcname string,
+	read *ArgArray, write *ArgArray, opts ...ExecOption) error {
+	if err := checkExec("vaccel_exec_with_resource", res, funcname, opts); err != nil {
+		return err
+	}
+	return NewError("vaccel_exec_with_resource",
+		execWithResourceArgs(sess, res, funcname, read, write))
+}
+
+func execWithResourceArgs(sess *Session, res *Resource, funcname string,
+	read *ArgArray, write *ArgArray) int {
+	if sess == nil || sess.cSess == nil || res == nil || res.cRes == nil {
+		return EINVAL
+	}
+	if sess.Poisoned() {
+		return EBUSY
+	}
+
+	cfunc := C.CString(funcname)
+	defer C.free(unsafe.Pointer(cfunc))
+
+	cRead, nrRead := read.cArgs()
+	cWrite, nrWrite := write.cArgs()
+
+	ret := int(C.vaccel_exec_with_resource(sess.cSess, res.cRes, cfunc,
+		cRead, C.size_t(nrRead), cWrite, C.size_t(nrWrite)))
+	runtime.KeepAlive(sess)
+	runtime.KeepAlive(res)
+	runtime.KeepAlive(read)
+	runtime.KeepAlive(write)
+	return ret
+}
+
+// ExecLibrary runs funcname of the shared object at library, which does not
+// have to be registered with sess as a Resource. A nil list has no
+// arguments, like in ExecLibraryArgs.
+func ExecLibrary(sess *Session, library string, funcname string,
+	read *ArgList, write *ArgList) int {
+	if sess == nil || sess.cSess == nil {
+		return EINVAL
+	}
+	if sess.Poisoned() {
+		return EBUSY
+	}
+
+	clib := C.CString(library)
+	defer C.free(unsafe.Pointer(clib))
+	cfunc := C.CString(funcname)
+	defer C.free(unsafe.Pointer(cfunc))
+
+	cRead, nrRead := read.cArgs()
+	cWrite, nrWrite := write.cArgs()
+
+	cRet := C.vaccel_exec(sess.cSess, clib, cfunc,
+		cRead, C.size_t(nrRead), cWrite, C.size_t(nrWrite))
+	runtime.KeepAlive(sess)
+	runtime.KeepAlive(read)
+	runtime.KeepAlive(write)
+	return int(cRet)
+}
+
+// ExecLibraryErr is like ExecLibrary but returns an error.
+func ExecLibraryErr(sess *Session, library string, funcname string,
+	read *ArgList, write *ArgList) error {
+	return NewError("vaccel_exec", ExecLibrary(sess, library, funcname, read, write))
+}
+
+// ExecLibraryCtx is like ExecLibraryErr but returns ctx.Err() as soon as
+// ctx is done, like ExecWithResourceCtx.
+func ExecLibraryCtx(ctx context.Context, sess *Session, library string, funcname string,
+	read *ArgList, write *ArgList) error {
+	rd, rdh := copyArgList(read)
+	wr, wrh := copyArgList(write)
+	_, err := sess.callCtx(ctx, "vaccel_exec", func() int {
+		return ExecLibrary(sess, library, funcname, rd, wr)
+	}, nil, rdh, wrh)
+	return err
+}
+
+// copyArgList returns a shallow copy of list, so that a call that outlives
+// its context does not see list being released, and the handle to pin for
+// it. A nil list is returned as is.
+func copyArgList(list *ArgList) (*ArgList, *handle) {
+	if list == nil {
+		return nil, nil
+	}
+	l := *list
+	return &l, l.h
+}
+
+// ExecLibraryArgs is like ExecLibraryErr but takes its arguments as
+// ArgArrays. A nil array has no arguments.
+func ExecLibraryArgs(sess *Session, library string, funcname string,
+	read *ArgArray, write *ArgArray) error {
+	return NewError("vaccel_exec", execLibraryArgs(sess, library, funcname, read, write))
+}
+
+func execLibraryArgs(sess *Session, library string, funcname string,
+	read *ArgArray, write *ArgArray) int {
+	if sess == nil || sess.cSess == nil {
+		return EINVAL
+	}
+	if sess.Poisoned() {
+		return EBUSY
+	}
+
+	clib := C.CString(library)
+	defer C.free(unsafe.Pointer(clib))
+	cfunc := C.CString(funcname)
+	defer C.free(unsafe.Pointer(cfunc))
+
+	cRead, nrRead := read.cArgs()
+	cWrite, nrWrite := write.cArgs()
+
+	ret := int(C.vaccel_exec(sess.cSess, clib, cfunc,
+		cRead, C.size_t(nrRead), cWrite, C.size_t(nrWrite)))
+	runtime.KeepAlive(sess)
+	runtime.KeepAlive(read)
+	runtime.KeepAlive(write)
+	return ret
+}
@@ -0,0 +1,220 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"context"
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Exec runs funcname of the shared object res, with the read arguments
+// taken from in and the write arguments decoded into the returned Out.
+//
+// If In is a struct, each of its exported fields is a read argument, in
+// order, encoded as with AddArg; otherwise in is the only read argument.
+// Likewise, each exported field of a struct Out, or Out itself, is a write
+// argument, allocated with the encoded size of its type and decoded as with
+// ExtractArg. Fields whose type does not have a fixed size, like slices or
+// types that implement encoding.BinaryUnmarshaler, must declare their size in
+// bytes with a `vaccel:"size=N"` tag, and fields tagged `vaccel:"-"` are
+// skipped. For example:
+//
+//	type in struct {
+//		N    int32
+//		Data []float32
+//	}
+//	type out struct {
+//		Sum    float32
+//		Result []float32 `vaccel:"size=64"`
+//	}
+//	res, err := vaccel.Exec[in, out](ctx, sess, lib, "myfunc", in{N: 16, Data: data})
+//
+// Exec returns ctx.Err() as soon as ctx is done, like ExecWithResourceCtx,
+// and takes the options of ExecWithResourceErr.
+func Exec[In, Out any](ctx context.Context, sess *Session, res *Resource, funcname string, in In, opts ...ExecOption) (Out, error) {
+	var out Out
+	if res == nil {
+		return out, NewError("vaccel_exec_with_resource", EINVAL)
+	}
+	if err := checkExec("vaccel_exec_with_resource", res, funcname, opts); err != nil {
+		return out, err
+	}
+	r := res.ref()
+	return execTyped[Out](ctx, sess, "vaccel_exec_with_resource", in, func(read, write *ArgArray) int {
+		return execWithResourceArgs(sess, r, funcname, read, write)
+	}, r.h)
+}
+
+// ExecLibraryTyped is like Exec but runs funcname of the shared object at
+// library, like ExecLibrary.
+func ExecLibraryTyped[In, Out any](ctx context.Context, sess *Session, library string, funcname string, in In) (Out, error) {
+	return execTyped[Out](ctx, sess, "vaccel_exec", in, func(read, write *ArgArray) int {
+		return execLibraryArgs(sess, library, funcname, read, write)
+	})
+}
+
+// execTyped lays out in and an Out as documented in Exec and runs them with
+// call, with the handles of the other inputs of call pinned.
+func execTyped[Out, In any](ctx context.Context, sess *Session, op string, in In,
+	call func(read, write *ArgArray) int, pins ...*handle) (Out, error) {
+	var out Out
+
+	read, err := newExecArgs(reflect.ValueOf(&in).Elem(), "In", addExecInput)
+	if err != nil {
+		return out, err
+	}
+	write, err := newExecArgs(reflect.ValueOf(&out).Elem(), "Out", addExecOutput)
+	if err != nil {
+		read.Close()
+		return out, err
+	}
+	closeArgs := func() {
+		read.Close()
+		write.Close()
+	}
+
+	completed, err := sess.callCtx(ctx, op, func() int {
+		return call(read, write)
+	}, closeArgs, pins...)
+	if !completed {
+		return out, err
+	}
+	defer closeArgs()
+	if err != nil {
+		return out, err
+	}
+
+	if err := decodeExecOutputs(write, reflect.ValueOf(&out).Elem()); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+// execField is an argument of Exec, with its declared size if any.
+type execField struct {
+	name string
+	v    reflect.Value
+	size int
+}
+
+// execFields returns the arguments of the addressable rv, which is the In
+// or the Out of Exec.
+func execFields(rv reflect.Value, name string) ([]execField, error) {
+	if rv.Kind() != reflect.Struct {
+		return []execField{{name: name, v: rv, size: -1}}, nil
+	}
+
+	var fields []execField
+	typ := rv.Type()
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		tag := f.Tag.Get("vaccel")
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+
+		field := execField{name: name + "." + f.Name, v: rv.Field(i), size: -1}
+		if sizeStr, ok := strings.CutPrefix(tag, "size="); ok {
+			size, err := strconv.Atoi(sizeStr)
+			if err != nil || size < 0 {
+				return nil, &Error{Code: EINVAL, Op: "Exec",
+					Msg: fmt.Sprintf("field %s has invalid tag %q", field.name, tag)}
+			}
+			field.size = size
+		} else if tag != "" {
+			return nil, &Error{Code: EINVAL, Op: "Exec",
+				Msg: fmt.Sprintf("field %s has invalid tag %q", field.name, tag)}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func newExecArgs(rv reflect.Value, name string, add func(*ArgArray, execField) error) (*ArgArray, error) {
+	fields, err := execFields(rv, name)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := NewArgArray(len(fields))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		if err := add(args, f); err != nil {
+			args.Close()
+			return nil, err
+		}
+	}
+	return args, nil
+}
+
+func addExecInput(args *ArgArray, f execField) error {
+	data, err := marshalValue(f.v)
+	if err != nil {
+		return &Error{Code: EINVAL, Op: "Exec", Msg: fmt.Sprintf("%s: %v", f.name, err)}
+	}
+	if f.size >= 0 && len(data) != f.size {
+		return &Error{Code: EINVAL, Op: "Exec",
+			Msg: fmt.Sprintf("%s is %d bytes, but its declared size is %d", f.name, len(data), f.size)}
+	}
+	return args.addArg("Exec", data)
+}
+
+func addExecOutput(args *ArgArray, f execField) error {
+	size := f.size
+	if size < 0 {
+		size = fixedSize(f.v.Type())
+	}
+	if size < 0 {
+		return &Error{Code: EINVAL, Op: "Exec",
+			Msg: fmt.Sprintf("%s of type %v does not have a fixed size; declare it with a `vaccel:\"size=N\"` tag", f.name, f.v.Type())}
+	}
+	return args.expectArg("Exec", size)
+}
+
+var binaryUnmarshalerType = reflect.TypeFor[encoding.BinaryUnmarshaler]()
+
+// fixedSize returns the encoded size of a value of typ, or -1 if it does
+// not have a fixed size or decodes itself.
+func fixedSize(typ reflect.Type) int {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.Slice || reflect.PointerTo(typ).Implements(binaryUnmarshalerType) {
+		return -1
+	}
+	return binary.Size(reflect.Zero(typ).Interface())
+}
+
+func decodeExecOutputs(args *ArgArray, rv reflect.Value) error {
+	fields, err := execFields(rv, "Out")
+	if err != nil {
+		return err
+	}
+
+	for i, f := range fields {
+		data, err := args.argData("Exec", i)
+		if err != nil {
+			return err
+		}
+
+		want := f.size
+		if want < 0 {
+			want = fixedSize(f.v.Type())
+		}
+		if len(data) != want {
+			return &Error{Code: EINVAL, Op: "Exec",
+				Msg: fmt.Sprintf("%s: got %d bytes, want %d", f.name, len(data), want)}
+		}
+		if err := unmarshalValue(data, f.v); err != nil {
+			return &Error{Code: EINVAL, Op: "Exec", Msg: fmt.Sprintf("%s: %v", f.name, err)}
+		}
+	}
+	return nil
+}
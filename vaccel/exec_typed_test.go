@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newTestLib(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "libtest.so")
+	if err := os.WriteFile(path, []byte{0x7f, 'E', 'L', 'F'}, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newTestLibResource(t *testing.T) *Resource {
+	t.Helper()
+
+	var res Resource
+	if err := res.InitErr(newTestLib(t), ResourceLib); err != nil {
+		t.Skipf("can not create resource: %v", err)
+	}
+	t.Cleanup(func() { res.Close() })
+	return &res
+}
+
+type execIn struct {
+	N       int32
+	Scale   float64
+	Data    []float32
+	Name    string
+	skipped int
+	Skipped int `vaccel:"-"`
+}
+
+type execOut struct {
+	Sum    float32
+	Values []float32 `vaccel:"size=8"`
+	Data   sizedData `vaccel:"size=12"`
+}
+
+func TestExecArgs(t *testing.T) {
+	in := execIn{N: 2, Scale: 0.5, Data: []float32{1, 2}, Name: "x", skipped: 1, Skipped: 1}
+	read, err := newExecArgs(reflect.ValueOf(&in).Elem(), "In", addExecInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer read.Close()
+
+	want := [][]byte{
+		binary.NativeEndian.AppendUint32(nil, 2),
+		binary.NativeEndian.AppendUint64(nil, 0x3fe0000000000000),
+		binary.NativeEndian.AppendUint32(binary.NativeEndian.AppendUint32(nil, 0x3f800000), 0x40000000),
+		[]byte("x\x00"),
+	}
+	if read.Len() != len(want) {
+		t.Fatalf("got %d read arguments, want %d", read.Len(), len(want))
+	}
+	for i := range want {
+		if got, _ := read.Bytes(i); string(got) != string(want[i]) {
+			t.Errorf("read argument %d: got %v, want %v", i, got, want[i])
+		}
+	}
+
+	var out execOut
+	write, err := newExecArgs(reflect.ValueOf(&out).Elem(), "Out", addExecOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer write.Close()
+
+	wantSizes := []int{4, 8, 12}
+	if write.Len() != len(wantSizes) {
+		t.Fatalf("got %d write arguments, want %d", write.Len(), len(wantSizes))
+	}
+	for i, size := range wantSizes {
+		if got, _ := write.Bytes(i); len(got) != size {
+			t.Errorf("write argument %d: got %d bytes, want %d", i, len(got), size)
+		}
+	}
+
+	/* decode results as written by a function */
+	results, err := newExecArgs(reflect.ValueOf(&struct {
+		Sum    float32
+		Values []float32
+		Data   sizedData
+	}{1.5, []float32{2, 3}, sizedData{Arr: []uint32{4, 5}}}).Elem(), "Out", addExecInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer results.Close()
+
+	if err := decodeExecOutputs(results, reflect.ValueOf(&out).Elem()); err != nil {
+		t.Fatal(err)
+	}
+	if out.Sum != 1.5 || len(out.Values) != 2 || out.Values[1] != 3 || len(out.Data.Arr) != 2 || out.Data.Arr[1] != 5 {
+		t.Errorf("got %+v", out)
+	}
+}
+
+func TestExecInvalid(t *testing.T) {
+	sess := newTestSession(t)
+	res := newTestLibResource(t)
+	ctx := context.Background()
+
+	_, err := Exec[int32, struct{ Out []byte }](ctx, sess, res, "f", 1)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("output without size: got %v, want %v", err, ErrInvalid)
+	}
+
+	_, err = Exec[int32, struct {
+		Out []byte `vaccel:"len=4"`
+	}](ctx, sess, res, "f", 1)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("invalid tag: got %v, want %v", err, ErrInvalid)
+	}
+
+	_, err = Exec[struct {
+		In []byte `vaccel:"size=4"`
+	}, int32](ctx, sess, res, "f", struct {
+		In []byte `vaccel:"size=4"`
+	}{In: []byte{1, 2}})
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("input size mismatch: got %v, want %v", err, ErrInvalid)
+	}
+
+	_, err = Exec[int, int32](ctx, sess, res, "f", 1)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("input without fixed size: got %v, want %v", err, ErrInvalid)
+	}
+
+	var short execOut
+	results, err := NewArgArray(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer results.Close()
+	if err := AddArg(results, int16(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := decodeExecOutputs(results, reflect.ValueOf(&short).Elem()); !errors.Is(err, ErrInvalid) {
+		t.Errorf("output size mismatch: got %v, want %v", err, ErrInvalid)
+	}
+}
+
+func TestExec(t *testing.T) {
+	sess := newTestSession(t)
+	res := newTestLibResource(t)
+
+	out, err := Exec[execIn, execOut](context.Background(), sess, res, "mytestfunc",
+		execIn{N: 2, Data: []float32{1, 2}})
+	skipIfNotSupported(t, err)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Values) != 2 {
+		t.Errorf("got %d values, want 2", len(out.Values))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Exec[int32, int32](ctx, sess, res, "mytestfunc", 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled context: got %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestExecLibrary(t *testing.T) {
+	sess := newTestSession(t)
+	lib := newTestLib(t)
+
+	out, err := ExecLibraryTyped[execIn, execOut](context.Background(), sess, lib, "mytestfunc",
+		execIn{N: 2, Data: []float32{1, 2}})
+	skipIfNotSupported(t, err)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Values) != 2 {
+		t.Errorf("got %d values, want 2", len(out.Values))
+	}
+
+	if err := ExecLibraryArgs(nil, lib, "mytestfunc", nil, nil); !errors.Is(err, ErrInvalid) {
+		t.Errorf("nil session: got %v, want %v", err, ErrInvalid)
+	}
+
+	/* nil lists have no arguments in both variants */
+	ret := ExecLibrary(sess, lib, "mytestfunc", nil, nil)
+	err = ExecLibraryArgs(sess, lib, "mytestfunc", nil, nil)
+	if (ret == OK) != (err == nil) {
+		t.Errorf("nil lists: ExecLibrary = %d, ExecLibraryArgs = %v, want the same result", ret, err)
+	}
+	if err := ExecLibraryCtx(context.Background(), sess, lib, "mytestfunc", nil, nil); (ret == OK) != (err == nil) {
+		t.Errorf("nil lists: ExecLibraryCtx = %v, ExecLibrary = %d, want the same result", err, ret)
+	}
+}
@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+// #include <vaccel/ops/fpga.h>
+import "C"
+import (
+	"fmt"
+	"math"
+	"runtime"
+)
+
+// FpgaArrayCopy copies in through the FPGA and returns the copy.
+func FpgaArrayCopy(sess *Session, in []int32) ([]int32, error) {
+	if err := checkFpga(sess, "vaccel_fpga_arraycopy", in); err != nil {
+		return nil, err
+	}
+
+	out := make([]int32, len(in))
+	ret := int(C.vaccel_fpga_arraycopy(sess.cSess,
+		(*C.int)(&in[0]), (*C.int)(&out[0]), C.size_t(len(in))))
+	runtime.KeepAlive(sess)
+	if ret != OK {
+		return nil, NewError("vaccel_fpga_arraycopy", ret)
+	}
+	return out, nil
+}
+
+// FpgaMMult multiplies the n x n row-major matrices a and b on the FPGA and
+// returns the n x n result. The plugin only takes square matrices, so n is
+// derived from the lengths of a and b, which must be equal perfect squares.
+func FpgaMMult(sess *Session, a, b []float32) ([]float32, error) {
+	if err := checkFpgaPair(sess, "vaccel_fpga_mmult", a, b); err != nil {
+		return nil, err
+	}
+	if n := int(math.Sqrt(float64(len(a)))); n*n != len(a) {
+		return nil, &Error{Code: EINVAL, Op: "vaccel_fpga_mmult",
+			Msg: fmt.Sprintf("input length %d is not the size of a square matrix", len(a))}
+	}
+
+	c := make([]float32, len(a))
+	ret := int(C.vaccel_fpga_mmult(sess.cSess,
+		(*C.float)(&a[0]), (*C.float)(&b[0]), (*C.float)(&c[0]),
+		C.size_t(len(a))))
+	runtime.KeepAlive(sess)
+	if ret != OK {
+		return nil, NewError("vaccel_fpga_mmult", ret)
+	}
+	return c, nil
+}
+
+// FpgaParallel adds and multiplies a and b element-wise on the FPGA, in
+// parallel, and returns the sums and the products.
+func FpgaParallel(sess *Session, a, b []float32) (sum, product []float32, err error) {
+	if err := checkFpgaPair(sess, "vaccel_fpga_parallel", a, b); err != nil {
+		return nil, nil, err
+	}
+
+	sum = make([]float32, len(a))
+	product = make([]float32, len(a))
+	ret := int(C.vaccel_fpga_parallel(sess.cSess,
+		(*C.float)(&a[0]), (*C.float)(&b[0]),
+		(*C.float)(&sum[0]), (*C.float)(&product[0]),
+		C.size_t(len(a))))
+	runtime.KeepAlive(sess)
+	if ret != OK {
+		return nil, nil, NewError("vaccel_fpga_parallel", ret)
+	}
+	return sum, product, nil
+}
+
+// FpgaVectorAdd adds the vectors a and b on the FPGA and returns the sum.
+func FpgaVectorAdd(sess *Session, a, b []float32) ([]float32, error) {
+	if err := checkFpgaPair(sess, "vaccel_fpga_vadd", a, b); err != nil {
+		return nil, err
+	}
+
+	c := make([]float32, len(a))
+	ret := int(C.vaccel_fpga_vadd(sess.cSess,
+		(*C.float)(&a[0]), (*C.float)(&b[0]), (*C.float)(&c[0]),
+		C.size_t(len(a)), C.size_t(len(b))))
+	runtime.KeepAlive(sess)
+	if ret != OK {
+		return nil, NewError("vaccel_fpga_vadd", ret)
+	}
+	return c, nil
+}
+
+func checkFpga[T int32 | float32](sess *Session, op string, in []T) error {
+	if sess == nil || sess.cSess == nil {
+		return NewError(op, EINVAL)
+	}
+	if len(in) == 0 {
+		return &Error{Code: EINVAL, Op: op, Msg: "empty input"}
+	}
+	return nil
+}
+
+func checkFpgaPair(sess *Session, op string, a, b []float32) error {
+	if err := checkFpga(sess, op, a); err != nil {
+		return err
+	}
+	if len(a) != len(b) {
+		return &Error{Code: EINVAL, Op: op,
+			Msg: fmt.Sprintf("inputs have different lengths %d and %d", len(a), len(b))}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestFpgaInvalid(t *testing.T) {
+	sess := newTestSession(t)
+	a := []float32{1, 2, 3}
+	b := []float32{4, 5}
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"ArrayCopy empty", func() error {
+			_, err := FpgaArrayCopy(sess, nil)
+			return err
+		}},
+		{"ArrayCopy nil session", func() error {
+			_, err := FpgaArrayCopy(nil, []int32{1})
+			return err
+		}},
+		{"MMult lengths", func() error {
+			_, err := FpgaMMult(sess, a, b)
+			return err
+		}},
+		{"MMult not square", func() error {
+			_, err := FpgaMMult(sess, a, a)
+			return err
+		}},
+		{"Parallel lengths", func() error {
+			_, _, err := FpgaParallel(sess, a, b)
+			return err
+		}},
+		{"VectorAdd lengths", func() error {
+			_, err := FpgaVectorAdd(sess, a, b)
+			return err
+		}},
+		{"VectorAdd empty", func() error {
+			_, err := FpgaVectorAdd(sess, nil, nil)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, ErrInvalid) {
+				t.Errorf("got %v, want %v", err, ErrInvalid)
+			}
+		})
+	}
+}
+
+func TestFpga(t *testing.T) {
+	sess := newTestSession(t)
+	a := []float32{1, 2, 3, 4}
+	b := []float32{5, 6, 7, 8}
+
+	t.Run("ArrayCopy", func(t *testing.T) {
+		in := []int32{1, 2, 3}
+		out, err := FpgaArrayCopy(sess, in)
+		skipIfNotSupported(t, err)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != len(in) {
+			t.Fatalf("got %d values, want %d", len(out), len(in))
+		}
+		if !slices.Equal(out, in) {
+			t.Errorf("got %v, want %v", out, in)
+		}
+	})
+
+	t.Run("MMult", func(t *testing.T) {
+		/* 2 x 2 matrices */
+		want := make([]float32, 4)
+		sgemmRef(2, 2, 2, 1, a, 2, b, 2, 0, want, 2)
+
+		c, err := FpgaMMult(sess, a, b)
+		skipIfNotSupported(t, err)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(c) != len(a) {
+			t.Fatalf("got %d values, want %d", len(c), len(a))
+		}
+		if !equalFloat32s(c, want) {
+			t.Errorf("got %v, want %v", c, want)
+		}
+	})
+
+	t.Run("Parallel", func(t *testing.T) {
+		sum, product, err := FpgaParallel(sess, a, b)
+		skipIfNotSupported(t, err)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sum) != len(a) || len(product) != len(a) {
+			t.Fatalf("got %d and %d values, want %d", len(sum), len(product), len(a))
+		}
+		for i := range a {
+			if sum[i] != a[i]+b[i] || product[i] != a[i]*b[i] {
+				t.Errorf("got %v + %v = %v, %v * %v = %v", a[i], b[i], sum[i], a[i], b[i], product[i])
+			}
+		}
+	})
+
+	t.Run("VectorAdd", func(t *testing.T) {
+		c, err := FpgaVectorAdd(sess, a, b)
+		skipIfNotSupported(t, err)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []float32{6, 8, 10, 12}
+		if len(c) != len(want) {
+			t.Fatalf("got %d values, want %d", len(c), len(want))
+		}
+		if !slices.Equal(c, want) {
+			t.Errorf("got %v, want %v", c, want)
+		}
+	})
+}
@@ -4,6 +4,7 @@ package vaccel
 
 // #include <vaccel/ops/genop.h>
 import "C"
+import "runtime"
 
 func Genop(sess *Session, read *ArgList, write *ArgList) int {
 	cRead := read.cList.list
@@ -13,6 +14,31 @@ func Genop(sess *Session, read *ArgList, write *ArgList) int {
 	cNrWrite := C.int(write.cList.size)
 
 	cRet := C.vaccel_genop(sess.cSess, cRead, cNrRead, cWrite, cNrWrite)
+	runtime.KeepAlive(sess)
+	runtime.KeepAlive(read)
+	runtime.KeepAlive(write)
 	return int(cRet)
 
 }
+
+// GenopErr is like Genop but returns an error.
+func GenopErr(sess *Session, read *ArgList, write *ArgList) error {
+	return NewError("vaccel_genop", Genop(sess, read, write))
+}
+
+// GenopArgs is like GenopErr but takes its arguments as ArgArrays. A nil
+// array has no arguments.
+func GenopArgs(sess *Session, read *ArgArray, write *ArgArray) error {
+	if sess == nil || sess.cSess == nil {
+		return NewError("vaccel_genop", EINVAL)
+	}
+
+	cRead, nrRead := read.cArgs()
+	cWrite, nrWrite := write.cArgs()
+
+	cRet := C.vaccel_genop(sess.cSess, cRead, C.int(nrRead), cWrite, C.int(nrWrite))
+	runtime.KeepAlive(sess)
+	runtime.KeepAlive(read)
+	runtime.KeepAlive(write)
+	return NewError("vaccel_genop", int(cRet))
+}
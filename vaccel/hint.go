@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+// #include <vaccel/plugin.h>
+import "C"
+import (
+	"fmt"
+	"strings"
+)
+
+// SessionHint is the set of plugin classes a session asks vAccel to run its
+// operations with.
+type SessionHint uint32
+
+const (
+	HintSoftware SessionHint = C.VACCEL_PLUGIN_SOFTWARE
+	HintHardware SessionHint = C.VACCEL_PLUGIN_HARDWARE
+	HintCPU      SessionHint = C.VACCEL_PLUGIN_CPU
+	HintGPU      SessionHint = C.VACCEL_PLUGIN_GPU
+	HintFPGA     SessionHint = C.VACCEL_PLUGIN_FPGA
+	HintRemote   SessionHint = C.VACCEL_PLUGIN_REMOTE
+	HintDebug    SessionHint = C.VACCEL_PLUGIN_DEBUG
+	HintGeneric  SessionHint = C.VACCEL_PLUGIN_GENERIC
+)
+
+var hintNames = []struct {
+	hint SessionHint
+	name string
+}{
+	{HintSoftware, "software"},
+	{HintHardware, "hardware"},
+	{HintCPU, "cpu"},
+	{HintGPU, "gpu"},
+	{HintFPGA, "fpga"},
+	{HintRemote, "remote"},
+	{HintDebug, "debug"},
+	{HintGeneric, "generic"},
+}
+
+const hintAll = HintSoftware | HintHardware | HintCPU | HintGPU | HintFPGA |
+	HintRemote | HintDebug | HintGeneric
+
+func (h SessionHint) String() string {
+	if h == 0 {
+		return "none"
+	}
+
+	var names []string
+	for _, n := range hintNames {
+		if h&n.hint != 0 {
+			names = append(names, n.name)
+		}
+	}
+	if unknown := h &^ hintAll; unknown != 0 {
+		names = append(names, fmt.Sprintf("0x%x", uint32(unknown)))
+	}
+
+	return strings.Join(names, "|")
+}
+
+// Validate reports whether h is a valid hint. A hint can not have unknown
+// bits set or ask for both software and hardware plugins.
+func (h SessionHint) Validate() error {
+	if unknown := h &^ hintAll; unknown != 0 {
+		return &Error{Code: EINVAL, Op: "SessionHint.Validate",
+			Msg: fmt.Sprintf("unknown hint bits 0x%x", uint32(unknown))}
+	}
+	if h&HintSoftware != 0 && h&HintHardware != 0 {
+		return &Error{Code: EINVAL, Op: "SessionHint.Validate",
+			Msg: "software and hardware hints are mutually exclusive"}
+	}
+	return nil
+}
+
+// IsRemote reports whether h selects a remote plugin.
+func (h SessionHint) IsRemote() bool {
+	return h&HintRemote != 0
+}
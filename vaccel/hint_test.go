@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSessionHintString(t *testing.T) {
+	tests := []struct {
+		hint SessionHint
+		want string
+	}{
+		{0, "none"},
+		{HintGPU, "gpu"},
+		{HintHardware | HintFPGA, "hardware|fpga"},
+		{HintRemote | HintCPU, "cpu|remote"},
+		{HintDebug | 1<<20, "debug|0x100000"},
+	}
+	for _, tt := range tests {
+		if got := tt.hint.String(); got != tt.want {
+			t.Errorf("SessionHint(0x%x).String() = %q, want %q", uint32(tt.hint), got, tt.want)
+		}
+	}
+}
+
+func TestSessionHintValidate(t *testing.T) {
+	valid := []SessionHint{0, HintCPU, HintSoftware | HintCPU, HintHardware | HintGPU | HintRemote, hintAll &^ HintHardware}
+	for _, h := range valid {
+		if err := h.Validate(); err != nil {
+			t.Errorf("%v.Validate() = %v, want nil", h, err)
+		}
+	}
+
+	invalid := []SessionHint{HintSoftware | HintHardware, 1 << 20, HintGPU | 1<<31}
+	for _, h := range invalid {
+		if err := h.Validate(); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%v.Validate() = %v, want ErrInvalid", h, err)
+		}
+	}
+}
+
+func TestSessionHintIsRemote(t *testing.T) {
+	if !(HintRemote | HintGPU).IsRemote() {
+		t.Errorf("remote hint is not remote")
+	}
+	if HintGPU.IsRemote() {
+		t.Errorf("local hint is remote")
+	}
+}
+
+func TestSessionOptions(t *testing.T) {
+	var o sessionOptions
+	for _, opt := range []SessionOption{WithLocal(), WithGPU(), WithHint(HintHardware)} {
+		opt(&o)
+	}
+	if o.hint != HintGPU|HintHardware || !o.local {
+		t.Errorf("options = %+v, want a local gpu|hardware hint", o)
+	}
+	if err := o.validate(); err != nil {
+		t.Errorf("validate: %v", err)
+	}
+
+	WithRemote()(&o)
+	if err := o.validate(); !errors.Is(err, ErrInvalid) {
+		t.Errorf("validate of local and remote options = %v, want ErrInvalid", err)
+	}
+
+	var uninit Session
+	if h := uninit.Hint(); h != 0 {
+		t.Errorf("Hint of an uninitialized session = %v, want none", h)
+	}
+}
+
+func TestWithPlugin(t *testing.T) {
+	t.Setenv("VACCEL_PLUGINS", "/usr/lib/libvaccel-noop.so:libvaccel-torch.so")
+
+	for _, name := range []string{"noop", "libvaccel-noop.so", "/usr/lib/libvaccel-noop.so", "torch"} {
+		var o sessionOptions
+		WithPlugin(name)(&o)
+		if err := o.validate(); err != nil {
+			t.Errorf("WithPlugin(%q): %v", name, err)
+		}
+	}
+	for _, name := range []string{"tf", "libvaccel-noop", "/lib/libvaccel-noop.so"} {
+		var o sessionOptions
+		WithPlugin(name)(&o)
+		if err := o.validate(); !errors.Is(err, ErrInvalid) {
+			t.Errorf("WithPlugin(%q) = %v, want ErrInvalid", name, err)
+		}
+	}
+
+	if _, err := NewSession(WithPlugin("tf")); !errors.Is(err, ErrInvalid) {
+		t.Errorf("NewSession with an unknown plugin = %v, want ErrInvalid", err)
+	}
+	sess, err := NewSession(WithPlugin("noop"))
+	if err != nil {
+		t.Skipf("can not create session: %v", err)
+	}
+	defer sess.Close()
+	if sess.Plugin() != "noop" {
+		t.Errorf("Plugin = %q, want %q", sess.Plugin(), "noop")
+	}
+}
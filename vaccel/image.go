@@ -0,0 +1,222 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+// #include <stdlib.h>
+import "C"
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"io/fs"
+	"os"
+	"runtime"
+	"unsafe"
+)
+
+const (
+	defaultImageOutputSize    = 256
+	defaultImageMaxOutputSize = 64 << 10
+)
+
+// ImageInput is an encoded image, e.g. JPEG or PNG, to run an image
+// operation on.
+type ImageInput interface {
+	imageBytes() ([]byte, error)
+}
+
+type imageBytes []byte
+
+func (b imageBytes) imageBytes() ([]byte, error) {
+	return b, nil
+}
+
+type imageFile string
+
+func (p imageFile) imageBytes() ([]byte, error) {
+	return os.ReadFile(string(p))
+}
+
+type imageReader struct {
+	r io.Reader
+}
+
+func (r imageReader) imageBytes() ([]byte, error) {
+	return io.ReadAll(r.r)
+}
+
+type imageImage struct {
+	img image.Image
+}
+
+func (i imageImage) imageBytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, i.img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ImageBytes returns an ImageInput of an encoded image in memory.
+func ImageBytes(b []byte) ImageInput {
+	return imageBytes(b)
+}
+
+// ImageFile returns an ImageInput of the image file at path.
+func ImageFile(path string) ImageInput {
+	return imageFile(path)
+}
+
+// ImageReader returns an ImageInput of the encoded image read from r.
+func ImageReader(r io.Reader) ImageInput {
+	return imageReader{r: r}
+}
+
+// ImageOf returns an ImageInput of img, encoded as PNG.
+func ImageOf(img image.Image) ImageInput {
+	return imageImage{img: img}
+}
+
+type imageOptions struct {
+	outputSize    int
+	maxOutputSize int
+}
+
+// ImageOption configures an image operation.
+type ImageOption func(*imageOptions)
+
+// WithOutputSize sets the initial size of the output buffers of an image
+// operation.
+func WithOutputSize(n int) ImageOption {
+	return func(o *imageOptions) {
+		o.outputSize = n
+	}
+}
+
+// WithMaxOutputSize sets the size up to which the output buffers of an
+// image operation are grown when an output does not fit.
+func WithMaxOutputSize(n int) ImageOption {
+	return func(o *imageOptions) {
+		o.maxOutputSize = n
+	}
+}
+
+func newImageOptions(opts []ImageOption) imageOptions {
+	o := imageOptions{
+		outputSize:    defaultImageOutputSize,
+		maxOutputSize: defaultImageMaxOutputSize,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.maxOutputSize = max(o.maxOutputSize, o.outputSize)
+
+	return o
+}
+
+// imageOpFunc calls an image operation of vAccel with the given image and
+// output buffers, all of outSize bytes.
+type imageOpFunc func(img unsafe.Pointer, imgLen C.size_t, outs []*C.uchar, outSize C.size_t) C.int
+
+// runImageOp reads the image of in and runs call with nrOuts output
+// buffers. If an output fills its buffer it may have been truncated, so
+// call is retried with larger buffers, up to the maximum output size.
+func runImageOp(sess *Session, op string, in ImageInput, nrOuts int, opts []ImageOption, call imageOpFunc) ([]string, error) {
+	if sess == nil || in == nil {
+		return nil, NewError(op, EINVAL)
+	}
+	if sess.Poisoned() {
+		return nil, &Error{Code: EBUSY, Op: op, Msg: "session has an abandoned call in flight"}
+	}
+
+	img, err := in.imageBytes()
+	if err != nil {
+		return nil, err
+	}
+	if len(img) == 0 {
+		return nil, &Error{Code: EINVAL, Op: op, Msg: "empty image"}
+	}
+
+	/* call passes sess to C */
+	defer runtime.KeepAlive(sess)
+
+	o := newImageOptions(opts)
+	if o.outputSize <= 0 {
+		return nil, &Error{Code: EINVAL, Op: op, Msg: "invalid output size"}
+	}
+
+	return growImageOutputs(op, o, func(size int) ([]string, bool, error) {
+		return callImageOp(op, img, nrOuts, size, call)
+	})
+}
+
+// growImageOutputs runs call with output buffers of the initial output size
+// of o, doubling it while an output may have been truncated, up to the
+// maximum output size of o.
+func growImageOutputs(op string, o imageOptions, call func(size int) ([]string, bool, error)) ([]string, error) {
+	for size := o.outputSize; ; size = min(2*size, o.maxOutputSize) {
+		outs, truncated, err := call(size)
+		if err != nil || !truncated {
+			return outs, err
+		}
+		if size == o.maxOutputSize {
+			return outs, &Error{Code: ENOSPC, Op: op, Msg: "output does not fit in the maximum output size"}
+		}
+	}
+}
+
+func callImageOp(op string, img []byte, nrOuts int, size int, call imageOpFunc) ([]string, bool, error) {
+	outs := make([]*C.uchar, nrOuts)
+	for i := range outs {
+		outs[i] = (*C.uchar)(C.calloc(C.size_t(size), 1))
+		if outs[i] == nil {
+			freeImageOutputs(outs)
+			return nil, false, NewError(op, ENOMEM)
+		}
+	}
+	defer freeImageOutputs(outs)
+
+	ret := int(call(unsafe.Pointer(&img[0]), C.size_t(len(img)), outs, C.size_t(size)))
+	if ret != OK {
+		return nil, false, NewError(op, ret)
+	}
+
+	truncated := false
+	results := make([]string, nrOuts)
+	for i, out := range outs {
+		var t bool
+		results[i], t = imageOutput(unsafe.Slice((*byte)(unsafe.Pointer(out)), size))
+		truncated = truncated || t
+	}
+
+	return results, truncated, nil
+}
+
+// imageOutput returns the NUL-terminated output in buf, and whether it
+// fills buf, in which case it may have been truncated.
+func imageOutput(buf []byte) (string, bool) {
+	n := bytes.IndexByte(buf, 0)
+	if n < 0 || n == len(buf)-1 {
+		return string(buf[:max(n, len(buf)-1)]), true
+	}
+	return string(buf[:n]), false
+}
+
+func freeImageOutputs(outs []*C.uchar) {
+	for _, out := range outs {
+		if out != nil {
+			C.free(unsafe.Pointer(out))
+		}
+	}
+}
+
+// imageFileError converts an error reading an image file to a vAccel error
+// code for the int-returning API.
+func imageFileError(err error) int {
+	if errors.Is(err, fs.ErrNotExist) {
+		return ENOENT
+	}
+	return EIO
+}
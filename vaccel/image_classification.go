@@ -5,8 +5,10 @@ package vaccel
 // #include <vaccel/ops/image.h>
 import "C"
 import (
-	"fmt"
+	"context"
 	"os"
+	"runtime"
+	"slices"
 	"unsafe"
 )
 
@@ -14,41 +16,21 @@ func ImageClassificationFromFile(sess *Session, imagePath string) (string, int)
 
 	imageBytes, err := os.ReadFile(imagePath)
 	if err != nil {
-		fmt.Printf("Error reading file: %s\n", err)
-		os.Exit(1)
+		return "", imageFileError(err)
 	}
 
-	cImageBytes := (*C.uchar)(&imageBytes[0])
-	cImgBuf := unsafe.Pointer(cImageBytes)
-	cImgLen := C.size_t(len(imageBytes))
-
-	cText := (*C.uchar)(C.malloc(C.size_t(256)))
-	cOutImageName := (*C.uchar)(C.malloc(C.size_t(256)))
-
-	/* Free the memory when done */
-	defer C.free(unsafe.Pointer(cText))
-	defer C.free(unsafe.Pointer(cOutImageName))
-
-	cRet := C.vaccel_image_classification(
-		sess.cSess, cImgBuf, cText, cOutImageName,
-		cImgLen, C.size_t(256), C.size_t(256))
-
-	var golangOut string
-
-	if int(cRet) == 0 {
-		ptr := unsafe.Pointer(cText)
-		typeCast := (*C.char)(ptr)
-		golangOut = C.GoString(typeCast)
-	} else {
-		golangOut =
-			"A problem occurred while running the Operation"
-	}
-
-	return golangOut, int(cRet)
+	return ImageClassification(sess, imageBytes)
 }
 
 func ImageClassification(sess *Session, image []byte) (string, int) {
 
+	if sess == nil || len(image) == 0 {
+		return "", EINVAL
+	}
+	if sess.Poisoned() {
+		return "", EBUSY
+	}
+
 	cImageBytes := (*C.uchar)(&image[0])
 	cImgBuf := unsafe.Pointer(cImageBytes)
 	cImgLen := C.size_t(len(image))
@@ -63,6 +45,7 @@ func ImageClassification(sess *Session, image []byte) (string, int) {
 	cRet := C.vaccel_image_classification(
 		sess.cSess, cImgBuf, cText, cOutImageName,
 		cImgLen, C.size_t(256), C.size_t(256))
+	runtime.KeepAlive(sess)
 
 	var golangOut string
 
@@ -77,3 +60,51 @@ func ImageClassification(sess *Session, image []byte) (string, int) {
 
 	return golangOut, int(cRet)
 }
+
+// ImageClassificationCtx is like ImageClassification but returns an error,
+// and returns ctx.Err() as soon as ctx is done. The C call can not be
+// interrupted, so it keeps running in the background and sess is poisoned
+// until it returns. The call works on a copy of image, so image can be
+// reused right away.
+func ImageClassificationCtx(ctx context.Context, sess *Session, image []byte) (string, error) {
+	if len(image) == 0 {
+		return "", NewError("vaccel_image_classification", EINVAL)
+	}
+	image = slices.Clone(image)
+
+	var out string
+	_, err := sess.callCtx(ctx, "vaccel_image_classification", func() int {
+		var ret int
+		out, ret = ImageClassification(sess, image)
+		return ret
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// ClassificationResult is the result of ClassifyImage.
+type ClassificationResult struct {
+	// Tag is the classification tag of the image
+	Tag string
+	// ImageName is the name of the output image, if any
+	ImageName string
+}
+
+// ClassifyImage runs image classification on in and returns its result.
+// The output buffers are sized with WithOutputSize and are grown, up to
+// WithMaxOutputSize, if an output does not fit.
+func ClassifyImage(sess *Session, in ImageInput, opts ...ImageOption) (ClassificationResult, error) {
+	outs, err := runImageOp(sess, "vaccel_image_classification", in, 2, opts,
+		func(img unsafe.Pointer, imgLen C.size_t, outs []*C.uchar, outSize C.size_t) C.int {
+			return C.vaccel_image_classification(
+				sess.cSess, img, outs[0], outs[1],
+				imgLen, outSize, outSize)
+		})
+	if err != nil {
+		return ClassificationResult{}, err
+	}
+
+	return ClassificationResult{Tag: outs[0], ImageName: outs[1]}, nil
+}
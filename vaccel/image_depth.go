@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+// #include <vaccel/ops/image.h>
+import "C"
+import "unsafe"
+
+// ImageDepth runs depth estimation on an encoded image and returns the name of
+// the estimated depth map.
+func ImageDepth(sess *Session, image []byte, opts ...ImageOption) (string, error) {
+	return imageDepth(sess, ImageBytes(image), opts)
+}
+
+// ImageDepthFromFile is like ImageDepth but reads the image from
+// imagePath.
+func ImageDepthFromFile(sess *Session, imagePath string, opts ...ImageOption) (string, error) {
+	return imageDepth(sess, ImageFile(imagePath), opts)
+}
+
+func imageDepth(sess *Session, in ImageInput, opts []ImageOption) (string, error) {
+	outs, err := runImageOp(sess, "vaccel_image_depth", in, 1, opts,
+		func(img unsafe.Pointer, imgLen C.size_t, outs []*C.uchar, outSize C.size_t) C.int {
+			return C.vaccel_image_depth(
+				sess.cSess, img, outs[0], imgLen, outSize)
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return outs[0], nil
+}
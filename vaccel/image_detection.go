@@ -5,8 +5,8 @@ package vaccel
 // #include <vaccel/ops/image.h>
 import "C"
 import (
-	"fmt"
 	"os"
+	"runtime"
 	"unsafe"
 )
 
@@ -14,39 +14,21 @@ func ImageDetectionFromFile(sess *Session, imagePath string) (string, int) {
 
 	imageBytes, err := os.ReadFile(imagePath)
 	if err != nil {
-		fmt.Printf("Error reading file: %s\n", err)
-		os.Exit(1)
+		return "", imageFileError(err)
 	}
 
-	cImageBytes := (*C.uchar)(&imageBytes[0])
-	cImgBuf := unsafe.Pointer(cImageBytes)
-	cImgLen := C.size_t(len(imageBytes))
-
-	cOutImageName := (*C.uchar)(C.malloc(C.size_t(1024)))
-
-	/* Free the memory when done */
-	defer C.free(unsafe.Pointer(cOutImageName))
-
-	cRet := C.vaccel_image_detection(
-		sess.cSess, cImgBuf, cOutImageName,
-		cImgLen, C.size_t(1024))
-
-	var golangOut string
-
-	if int(cRet) == 0 {
-		ptr := unsafe.Pointer(cOutImageName)
-		typeCast := (*C.char)(ptr)
-		golangOut = C.GoString(typeCast)
-	} else {
-		golangOut =
-			"A problem occurred while running the Operation"
-	}
-
-	return golangOut, int(cRet)
+	return ImageDetection(sess, imageBytes)
 }
 
 func ImageDetection(sess *Session, image []byte) (string, int) {
 
+	if sess == nil || len(image) == 0 {
+		return "", EINVAL
+	}
+	if sess.Poisoned() {
+		return "", EBUSY
+	}
+
 	cImageBytes := (*C.uchar)(&image[0])
 	cImgBuf := unsafe.Pointer(cImageBytes)
 	cImgLen := C.size_t(len(image))
@@ -59,6 +41,7 @@ func ImageDetection(sess *Session, image []byte) (string, int) {
 	cRet := C.vaccel_image_detection(
 		sess.cSess, cImgBuf, cOutImageName,
 		cImgLen, C.size_t(1024))
+	runtime.KeepAlive(sess)
 
 	var golangOut string
 
@@ -73,3 +56,25 @@ func ImageDetection(sess *Session, image []byte) (string, int) {
 
 	return golangOut, int(cRet)
 }
+
+// DetectionResult is the result of DetectImage.
+type DetectionResult struct {
+	// ImageName is the name of the output image with the detected objects
+	ImageName string
+}
+
+// DetectImage runs object detection on in and returns its result. The
+// output buffer is sized with WithOutputSize and is grown, up to
+// WithMaxOutputSize, if the output does not fit.
+func DetectImage(sess *Session, in ImageInput, opts ...ImageOption) (DetectionResult, error) {
+	outs, err := runImageOp(sess, "vaccel_image_detection", in, 1, opts,
+		func(img unsafe.Pointer, imgLen C.size_t, outs []*C.uchar, outSize C.size_t) C.int {
+			return C.vaccel_image_detection(
+				sess.cSess, img, outs[0], imgLen, outSize)
+		})
+	if err != nil {
+		return DetectionResult{}, err
+	}
+
+	return DetectionResult{ImageName: outs[0]}, nil
+}
@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+// #include <vaccel/ops/image.h>
+import "C"
+import "unsafe"
+
+// ImagePose runs pose estimation on an encoded image and returns the name of
+// the image with the estimated pose.
+func ImagePose(sess *Session, image []byte, opts ...ImageOption) (string, error) {
+	return imagePose(sess, ImageBytes(image), opts)
+}
+
+// ImagePoseFromFile is like ImagePose but reads the image from
+// imagePath.
+func ImagePoseFromFile(sess *Session, imagePath string, opts ...ImageOption) (string, error) {
+	return imagePose(sess, ImageFile(imagePath), opts)
+}
+
+func imagePose(sess *Session, in ImageInput, opts []ImageOption) (string, error) {
+	outs, err := runImageOp(sess, "vaccel_image_pose", in, 1, opts,
+		func(img unsafe.Pointer, imgLen C.size_t, outs []*C.uchar, outSize C.size_t) C.int {
+			return C.vaccel_image_pose(
+				sess.cSess, img, outs[0], imgLen, outSize)
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return outs[0], nil
+}
@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+// #include <vaccel/ops/image.h>
+import "C"
+import "unsafe"
+
+// ImageSegmentation runs image segmentation on an encoded image and returns the name of
+// the segmented image.
+func ImageSegmentation(sess *Session, image []byte, opts ...ImageOption) (string, error) {
+	return imageSegmentation(sess, ImageBytes(image), opts)
+}
+
+// ImageSegmentationFromFile is like ImageSegmentation but reads the image from
+// imagePath.
+func ImageSegmentationFromFile(sess *Session, imagePath string, opts ...ImageOption) (string, error) {
+	return imageSegmentation(sess, ImageFile(imagePath), opts)
+}
+
+func imageSegmentation(sess *Session, in ImageInput, opts []ImageOption) (string, error) {
+	outs, err := runImageOp(sess, "vaccel_image_segmentation", in, 1, opts,
+		func(img unsafe.Pointer, imgLen C.size_t, outs []*C.uchar, outSize C.size_t) C.int {
+			return C.vaccel_image_segmentation(
+				sess.cSess, img, outs[0], imgLen, outSize)
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return outs[0], nil
+}
@@ -0,0 +1,239 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// poisonSession leaves a call of sess abandoned until the returned function
+// is called.
+func poisonSession(t *testing.T, sess *Session) func() {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started, unblock := make(chan struct{}), make(chan struct{})
+	go func() {
+		<-started
+		cancel()
+	}()
+	if completed, err := sess.callCtx(ctx, "test", func() int {
+		close(started)
+		<-unblock
+		return OK
+	}, nil); completed || !errors.Is(err, context.Canceled) {
+		t.Fatalf("callCtx = %v, %v, want false, context.Canceled", completed, err)
+	}
+
+	return func() {
+		close(unblock)
+		sess.waitSettled()
+	}
+}
+
+// newTestImage returns a small image and its PNG encoding.
+func newTestImage(t *testing.T) (image.Image, []byte) {
+	t.Helper()
+
+	img := image.NewGray(image.Rect(0, 0, 2, 2))
+	img.SetGray(1, 1, color.Gray{Y: 0xff})
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	return img, buf.Bytes()
+}
+
+func TestImageInputs(t *testing.T) {
+	img, data := newTestImage(t)
+	path := filepath.Join(t.TempDir(), "image.png")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, in := range map[string]ImageInput{
+		"ImageBytes":  ImageBytes(data),
+		"ImageFile":   ImageFile(path),
+		"ImageReader": ImageReader(bytes.NewReader(data)),
+		"ImageOf":     ImageOf(img),
+	} {
+		got, err := in.imageBytes()
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("%s = %d bytes, want the %d bytes of the image", name, len(got), len(data))
+		}
+	}
+}
+
+func TestImageInputErrors(t *testing.T) {
+	sess := newTestSession(t)
+	missing := filepath.Join(t.TempDir(), "missing.png")
+
+	if _, err := ClassifyImage(sess, ImageFile(missing)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ClassifyImage of a missing file = %v, want fs.ErrNotExist", err)
+	}
+	if _, ret := ImageClassificationFromFile(sess, missing); ret != ENOENT {
+		t.Errorf("ImageClassificationFromFile of a missing file = %d, want ENOENT", ret)
+	}
+	if _, ret := ImageDetectionFromFile(sess, missing); ret != ENOENT {
+		t.Errorf("ImageDetectionFromFile of a missing file = %d, want ENOENT", ret)
+	}
+
+	fail := errors.New("fail")
+	if _, err := DetectImage(sess, ImageReader(iotest.ErrReader(fail))); !errors.Is(err, fail) {
+		t.Errorf("DetectImage of a failing reader = %v, want %v", err, fail)
+	}
+
+	for name, in := range map[string]ImageInput{
+		"nil":   nil,
+		"empty": ImageBytes(nil),
+	} {
+		if _, err := ClassifyImage(sess, in); !errors.Is(err, ErrInvalid) {
+			t.Errorf("ClassifyImage of a %s input = %v, want ErrInvalid", name, err)
+		}
+		if _, err := DetectImage(sess, in); !errors.Is(err, ErrInvalid) {
+			t.Errorf("DetectImage of a %s input = %v, want ErrInvalid", name, err)
+		}
+	}
+	if _, err := ClassifyImage(sess, ImageBytes([]byte{0}), WithOutputSize(0)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("ClassifyImage with an output size of 0 = %v, want ErrInvalid", err)
+	}
+}
+
+func TestClassifyDetectImage(t *testing.T) {
+	sess := newTestSession(t)
+	img, data := newTestImage(t)
+
+	for name, in := range map[string]ImageInput{
+		"ImageBytes":  ImageBytes(data),
+		"ImageReader": ImageReader(bytes.NewReader(data)),
+		"ImageOf":     ImageOf(img),
+	} {
+		_, err := ClassifyImage(sess, in)
+		skipIfNotSupported(t, err)
+		if err != nil {
+			t.Errorf("ClassifyImage of %s: %v", name, err)
+		}
+	}
+
+	_, err := DetectImage(sess, ImageBytes(data), WithOutputSize(16))
+	skipIfNotSupported(t, err)
+	if err != nil {
+		t.Errorf("DetectImage: %v", err)
+	}
+}
+
+func TestImageOutput(t *testing.T) {
+	tests := []struct {
+		buf       string
+		want      string
+		truncated bool
+	}{
+		{"tag\x00\x00\x00", "tag", false},
+		{"tags\x00\x00", "tags", false},
+		{"tagsx\x00", "tagsx", true},
+		{"tagsxy", "tagsx", true},
+		{"\x00\x00\x00", "", false},
+	}
+	for _, tt := range tests {
+		got, truncated := imageOutput([]byte(tt.buf))
+		if got != tt.want || truncated != tt.truncated {
+			t.Errorf("imageOutput(%q) = %q, %v, want %q, %v", tt.buf, got, truncated, tt.want, tt.truncated)
+		}
+	}
+}
+
+// fakeImageOp returns a call for growImageOutputs that writes out to buffers
+// of the requested size, like vAccel does, and records the sizes.
+func fakeImageOp(out string, sizes *[]int) func(int) ([]string, bool, error) {
+	return func(size int) ([]string, bool, error) {
+		*sizes = append(*sizes, size)
+		buf := make([]byte, size)
+		copy(buf[:size-1], out)
+		got, truncated := imageOutput(buf)
+		return []string{got}, truncated, nil
+	}
+}
+
+func TestGrowImageOutputs(t *testing.T) {
+	out := strings.Repeat("x", 20)
+
+	var sizes []int
+	o := imageOptions{outputSize: 4, maxOutputSize: 64}
+	outs, err := growImageOutputs("test", o, fakeImageOp(out, &sizes))
+	if err != nil {
+		t.Fatalf("growImageOutputs: %v", err)
+	}
+	if len(outs) != 1 || outs[0] != out {
+		t.Errorf("outputs = %q, want [%q]", outs, out)
+	}
+	if want := []int{4, 8, 16, 32}; !slices.Equal(sizes, want) {
+		t.Errorf("output sizes = %v, want %v", sizes, want)
+	}
+
+	/* the output does not fit even in the maximum output size */
+	sizes = nil
+	o = imageOptions{outputSize: 4, maxOutputSize: 12}
+	outs, err = growImageOutputs("test", o, fakeImageOp(out, &sizes))
+	if !errors.Is(err, ErrNoSpace) {
+		t.Errorf("growImageOutputs = %v, want ErrNoSpace", err)
+	}
+	if len(outs) != 1 || outs[0] != out[:11] {
+		t.Errorf("truncated outputs = %q, want [%q]", outs, out[:11])
+	}
+	if want := []int{4, 8, 12}; !slices.Equal(sizes, want) {
+		t.Errorf("output sizes = %v, want %v", sizes, want)
+	}
+
+	/* errors are returned right away */
+	fail := errors.New("fail")
+	calls := 0
+	if _, err := growImageOutputs("test", o, func(int) ([]string, bool, error) {
+		calls++
+		return nil, true, fail
+	}); !errors.Is(err, fail) || calls != 1 {
+		t.Errorf("growImageOutputs = %v after %d calls, want %v after 1 call", err, calls, fail)
+	}
+}
+
+func TestImagePoisonedSession(t *testing.T) {
+	sess := newTestSession(t)
+	defer poisonSession(t, sess)()
+
+	img := []byte{0}
+	if _, ret := ImageDetection(sess, img); ret != EBUSY {
+		t.Errorf("ImageDetection on a poisoned session = %d, want EBUSY", ret)
+	}
+
+	for name, op := range map[string]func(*Session, []byte, ...ImageOption) (string, error){
+		"ImageSegmentation": ImageSegmentation,
+		"ImagePose":         ImagePose,
+		"ImageDepth":        ImageDepth,
+	} {
+		if _, err := op(sess, img); !errors.Is(err, ErrBusy) {
+			t.Errorf("%s on a poisoned session = %v, want ErrBusy", name, err)
+		}
+	}
+	if _, err := ClassifyImage(sess, ImageBytes(img)); !errors.Is(err, ErrBusy) {
+		t.Errorf("ClassifyImage on a poisoned session = %v, want ErrBusy", err)
+	}
+	if _, err := DetectImage(sess, ImageBytes(img)); !errors.Is(err, ErrBusy) {
+		t.Errorf("DetectImage on a poisoned session = %v, want ErrBusy", err)
+	}
+}
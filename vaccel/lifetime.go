@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// handle owns a C allocation on behalf of a Go value. Copies of the value
+// share the handle, so the allocation is released exactly once, either
+// explicitly through close or by a cleanup when no copy is reachable anymore.
+// Code that passes the allocation to C, or reads it, must keep the value
+// alive with runtime.KeepAlive until it is done, as the cleanup can run as
+// soon as the value is last used.
+//
+// A copy keeps the raw C pointer of the value, which dangles once the
+// original is closed. Session and Resource must only be used through
+// pointers once initialized, and go vet reports copies of them, as they hold
+// a lock or a noCopy.
+type handle struct {
+	state *handleState
+}
+
+// noCopy makes go vet report copies of the struct that embeds it, through
+// its copylocks check.
+type noCopy struct{}
+
+func (*noCopy) Lock()   {}
+func (*noCopy) Unlock() {}
+
+// handleState is kept separate from handle so the cleanup attached to the
+// handle does not keep it reachable.
+type handleState struct {
+	mu       sync.Mutex
+	kind     string
+	release  func() int
+	released bool
+	stack    []byte
+
+	// number of calls that still use the C allocation, and whether it was
+	// closed while in use
+	pins    int
+	pending bool
+}
+
+func newHandle(kind string, release func() int) *handle {
+	st := &handleState{kind: kind, release: release}
+	if leakDebug {
+		st.stack = debug.Stack()
+	}
+
+	h := &handle{state: st}
+	runtime.AddCleanup(h, (*handleState).leaked, st)
+
+	return h
+}
+
+// close releases the C allocation. Only the first call releases anything;
+// later calls return OK. If the handle is pinned, the release is deferred
+// until it is unpinned and close returns OK right away.
+func (h *handle) close() int {
+	st := h.state
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.released {
+		return OK
+	}
+	if st.pins > 0 {
+		st.pending = true
+		return OK
+	}
+
+	ret := st.release()
+	if ret == OK {
+		st.released = true
+	}
+	return ret
+}
+
+// pin keeps the C allocation from being released by close until unpin is
+// called, e.g. while a call abandoned by its context still uses it. It is a
+// no-op for a nil handle.
+func (h *handle) pin() {
+	if h == nil {
+		return
+	}
+	st := h.state
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.pins++
+}
+
+// unpin undoes pin, and runs the release deferred by close, if any, when
+// no pins are left.
+func (h *handle) unpin() {
+	if h == nil {
+		return
+	}
+	st := h.state
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.pins--
+	if st.pins > 0 || !st.pending || st.released {
+		return
+	}
+	st.pending = false
+	if ret := st.release(); ret == OK {
+		st.released = true
+	} else if leakDebug {
+		fmt.Fprintf(os.Stderr, "vaccel: deferred release of %s failed: %d\n", st.kind, ret)
+	}
+}
+
+// chain makes the handle run f once the C allocation has been released.
+func (h *handle) chain(f func()) {
+	st := h.state
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	release := st.release
+	st.release = func() int {
+		ret := release()
+		if ret == OK {
+			f()
+		}
+		return ret
+	}
+}
+
+func (st *handleState) leaked() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.released {
+		return
+	}
+
+	if leakDebug {
+		fmt.Fprintf(os.Stderr, "vaccel: %s was not closed; allocated at:\n%s\n", st.kind, st.stack)
+	}
+	st.release()
+	st.released = true
+}
+
+var (
+	_ io.Closer = (*Session)(nil)
+	_ io.Closer = (*Resource)(nil)
+	_ io.Closer = (*Blob)(nil)
+	_ io.Closer = (*ArgList)(nil)
+	_ io.Closer = (*ArgArray)(nil)
+	_ io.Closer = (*TFBuffer)(nil)
+	_ io.Closer = (*TFNode)(nil)
+	_ io.Closer = (*TFTensor)(nil)
+	_ io.Closer = (*TorchTensor)(nil)
+	_ io.Closer = (*TFLiteTensor)(nil)
+)
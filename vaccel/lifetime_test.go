@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import "testing"
+
+func TestHandlePin(t *testing.T) {
+	releases := 0
+	h := newHandle("test", func() int {
+		releases++
+		return OK
+	})
+
+	h.pin()
+	h.pin()
+	if ret := h.close(); ret != OK {
+		t.Fatalf("close of a pinned handle = %d, want OK", ret)
+	}
+	h.unpin()
+	if releases != 0 {
+		t.Fatalf("released with a pin left")
+	}
+	h.unpin()
+	if releases != 1 {
+		t.Fatalf("got %d releases after the last unpin, want 1", releases)
+	}
+
+	if ret := h.close(); ret != OK || releases != 1 {
+		t.Errorf("close after a deferred release = %d with %d releases, want OK and 1", ret, releases)
+	}
+
+	/* unpinning without a pending close releases nothing */
+	h2 := newHandle("test", func() int {
+		releases++
+		return OK
+	})
+	h2.pin()
+	h2.unpin()
+	if releases != 1 {
+		t.Errorf("unpin released a handle that was not closed")
+	}
+	h2.close()
+
+	/* nil handles, of uninitialized values, can be pinned */
+	var nilHandle *handle
+	nilHandle.pin()
+	nilHandle.unpin()
+}
@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+// #include <vaccel/ops/minmax.h>
+import "C"
+import (
+	"fmt"
+	"math"
+	"runtime"
+)
+
+// MinMax runs the minmax operation on the first ndata values of in, with
+// the given thresholds. It returns the processed values and the minimum and
+// maximum of the input.
+func MinMax(sess *Session, in []float64, ndata int, lowThreshold, highThreshold int) (out []float64, minV, maxV float64, err error) {
+	if sess == nil || sess.cSess == nil {
+		return nil, 0, 0, NewError("vaccel_minmax", EINVAL)
+	}
+	if ndata <= 0 || ndata > len(in) || ndata > math.MaxInt32 {
+		return nil, 0, 0, &Error{Code: EINVAL, Op: "vaccel_minmax",
+			Msg: fmt.Sprintf("ndata=%d is out of range for %d input values", ndata, len(in))}
+	}
+	if !fitsCInt(lowThreshold) || !fitsCInt(highThreshold) {
+		return nil, 0, 0, &Error{Code: EINVAL, Op: "vaccel_minmax",
+			Msg: fmt.Sprintf("thresholds %d and %d are out of range", lowThreshold, highThreshold)}
+	}
+
+	out = make([]float64, ndata)
+	var cMin, cMax C.double
+	ret := int(C.vaccel_minmax(sess.cSess,
+		(*C.double)(&in[0]), C.int(ndata),
+		C.int(lowThreshold), C.int(highThreshold),
+		(*C.double)(&out[0]), &cMin, &cMax))
+	runtime.KeepAlive(sess)
+	if ret != OK {
+		return nil, 0, 0, NewError("vaccel_minmax", ret)
+	}
+
+	return out, float64(cMin), float64(cMax), nil
+}
+
+func fitsCInt(v int) bool {
+	return v >= math.MinInt32 && v <= math.MaxInt32
+}
@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestMinMaxInvalid(t *testing.T) {
+	sess := newTestSession(t)
+	in := []float64{3, 1, 2}
+
+	tests := []struct {
+		name      string
+		in        []float64
+		ndata     int
+		low, high int
+	}{
+		{"empty", nil, 0, 0, 10},
+		{"zero ndata", in, 0, 0, 10},
+		{"short input", in, 4, 0, 10},
+		{"threshold", in, 3, 0, 1 << 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := MinMax(sess, tt.in, tt.ndata, tt.low, tt.high)
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("got %v, want %v", err, ErrInvalid)
+			}
+		})
+	}
+
+	if _, _, _, err := MinMax(nil, in, 3, 0, 10); !errors.Is(err, ErrInvalid) {
+		t.Errorf("nil session: got %v, want %v", err, ErrInvalid)
+	}
+}
+
+func TestMinMax(t *testing.T) {
+	sess := newTestSession(t)
+	in := []float64{5, -2, 7.5, 3, 11}
+
+	out, minV, maxV, err := MinMax(sess, in, 4, 0, 10)
+	skipIfNotSupported(t, err)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 4 {
+		t.Fatalf("got %d output values, want 4", len(out))
+	}
+	if minV != slices.Min(in[:4]) || maxV != slices.Max(in[:4]) {
+		t.Errorf("got min %v max %v, want %v %v", minV, maxV, slices.Min(in[:4]), slices.Max(in[:4]))
+	}
+}
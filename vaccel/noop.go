@@ -4,7 +4,14 @@ package vaccel
 
 // #include <vaccel/ops/noop.h>
 import "C"
+import "runtime"
 
 func NoOp(sess *Session) int {
+	defer runtime.KeepAlive(sess)
 	return int(C.vaccel_noop(sess.cSess))
 }
+
+// NoOpErr is like NoOp but returns an error.
+func NoOpErr(sess *Session) error {
+	return NewError("vaccel_noop", NoOp(sess))
+}
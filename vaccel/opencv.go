@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+// #include <vaccel/ops/opencv.h>
+import "C"
+import "runtime"
+
+// OpenCV runs the operation of the OpenCV plugin on the image img and returns
+// the processed image, which has the size of img. The read arguments of the
+// operation are the image and its length, which is passed as a uint64 like
+// the size_t lengths of the other vAccel image operations, and its write
+// argument is the processed image.
+func OpenCV(sess *Session, img []byte) ([]byte, error) {
+	if sess == nil || sess.cSess == nil {
+		return nil, NewError("vaccel_opencv", EINVAL)
+	}
+	if len(img) == 0 {
+		return nil, &Error{Code: EINVAL, Op: "vaccel_opencv", Msg: "empty image"}
+	}
+
+	read, write, err := openCVArgs(img)
+	if err != nil {
+		return nil, err
+	}
+	defer read.Close()
+	defer write.Close()
+
+	rArgs, nrRead := read.cArgs()
+	wArgs, nrWrite := write.cArgs()
+	ret := int(C.vaccel_opencv(sess.cSess,
+		rArgs, C.int(nrRead), wArgs, C.int(nrWrite)))
+	runtime.KeepAlive(sess)
+	runtime.KeepAlive(read)
+	runtime.KeepAlive(write)
+	if ret != OK {
+		return nil, NewError("vaccel_opencv", ret)
+	}
+
+	return ExtractArg[[]byte](write, 0)
+}
+
+// openCVArgs returns the read and write arguments of OpenCV for img.
+func openCVArgs(img []byte) (read, write *ArgArray, err error) {
+	read, err = NewArgArray(2)
+	if err != nil {
+		return nil, nil, err
+	}
+	write, err = NewArgArray(1)
+	if err != nil {
+		read.Close()
+		return nil, nil, err
+	}
+
+	err = AddArg(read, img)
+	if err == nil {
+		err = AddArg(read, uint64(len(img)))
+	}
+	if err == nil {
+		err = write.AddBytes(make([]byte, len(img)))
+	}
+	if err != nil {
+		read.Close()
+		write.Close()
+		return nil, nil, err
+	}
+	return read, write, nil
+}
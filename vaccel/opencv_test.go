@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func TestOpenCVArgs(t *testing.T) {
+	img := []byte{1, 2, 3}
+	read, write, err := openCVArgs(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer read.Close()
+	defer write.Close()
+
+	if read.Len() != 2 || write.Len() != 1 {
+		t.Fatalf("got %d read and %d write arguments, want 2 and 1", read.Len(), write.Len())
+	}
+	if got, err := ExtractArg[[]byte](read, 0); err != nil || !bytes.Equal(got, img) {
+		t.Errorf("image argument = %v, %v, want %v", got, err, img)
+	}
+	if got, err := read.Bytes(1); err != nil || !bytes.Equal(got, binary.NativeEndian.AppendUint64(nil, 3)) {
+		t.Errorf("length argument = %v, %v, want 3 as a native uint64", got, err)
+	}
+	if got, err := ExtractArg[uint64](read, 1); err != nil || got != 3 {
+		t.Errorf("ExtractArg of the length = %d, %v, want 3", got, err)
+	}
+	if got, err := write.Bytes(0); err != nil || !bytes.Equal(got, make([]byte, len(img))) {
+		t.Errorf("output argument = %v, %v, want %d zero bytes", got, err, len(img))
+	}
+}
+
+func TestOpenCVInvalid(t *testing.T) {
+	sess := newTestSession(t)
+
+	if _, err := OpenCV(nil, []byte{1}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("nil session: got %v, want %v", err, ErrInvalid)
+	}
+	if _, err := OpenCV(sess, nil); !errors.Is(err, ErrInvalid) {
+		t.Errorf("empty image: got %v, want %v", err, ErrInvalid)
+	}
+}
+
+func TestOpenCV(t *testing.T) {
+	sess := newTestSession(t)
+
+	img := make([]byte, 16)
+	out, err := OpenCV(sess, img)
+	skipIfNotSupported(t, err)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != len(img) {
+		t.Errorf("got %d bytes, want %d", len(out), len(img))
+	}
+}
@@ -0,0 +1,309 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ModelLoader loads a model resource on a session and unloads it before the
+// session is released. Unload may be nil for frameworks without an unload
+// operation.
+type ModelLoader struct {
+	Load   func(sess *Session, model *Resource) error
+	Unload func(sess *Session, model *Resource) error
+}
+
+// Model loaders of the supported frameworks.
+var (
+	TFModelLoader = ModelLoader{
+		Load: func(sess *Session, model *Resource) error {
+			var status TFStatus
+			defer status.Release()
+			return TFModelLoadErr(sess, model, &status)
+		},
+		Unload: func(sess *Session, model *Resource) error {
+			var status TFStatus
+			defer status.Release()
+			return TFModelUnloadErr(sess, model, &status)
+		},
+	}
+	TorchModelLoader = ModelLoader{
+		Load: TorchModelLoadErr,
+	}
+	TFLiteModelLoader = ModelLoader{
+		Load:   TFLiteModelLoadErr,
+		Unload: TFLiteModelUnloadErr,
+	}
+)
+
+type poolModel struct {
+	res    *Resource
+	loader ModelLoader
+}
+
+type poolOptions struct {
+	sessOpts  []SessionOption
+	resources []*Resource
+	models    []poolModel
+}
+
+// PoolOption configures a pool created with NewSessionPool.
+type PoolOption func(*poolOptions)
+
+// WithSessionOptions creates the sessions of the pool with opts.
+func WithSessionOptions(opts ...SessionOption) PoolOption {
+	return func(o *poolOptions) {
+		o.sessOpts = append(o.sessOpts, opts...)
+	}
+}
+
+// WithResources registers res with every session of the pool.
+func WithResources(res ...*Resource) PoolOption {
+	return func(o *poolOptions) {
+		o.resources = append(o.resources, res...)
+	}
+}
+
+// WithModel registers model with every session of the pool and loads it
+// with loader.
+func WithModel(model *Resource, loader ModelLoader) PoolOption {
+	return func(o *poolOptions) {
+		o.models = append(o.models, poolModel{res: model, loader: loader})
+	}
+}
+
+// PoolStats holds statistics of a SessionPool.
+type PoolStats struct {
+	Size     int           // number of sessions of the pool
+	Idle     int           // sessions available to Acquire
+	Busy     int           // sessions acquired and not released yet
+	Settling int           // poisoned sessions released, waiting for their abandoned calls
+	Acquires uint64        // successful Acquire calls
+	Waits    uint64        // Acquire calls that had to wait for a session
+	WaitTime time.Duration // total time Acquire calls waited
+	MaxWait  time.Duration // longest time an Acquire call waited
+}
+
+// SessionPool is a fixed set of sessions, configured the same way, for
+// running operations from several goroutines in parallel. A session is
+// used by one goroutine at a time, between Acquire and Release. All the
+// methods of a SessionPool are safe for concurrent use.
+type SessionPool struct {
+	models []poolModel
+	idle   chan *Session
+	done   chan struct{}
+
+	mu     sync.Mutex
+	busy   map[*Session]bool
+	closed bool
+	stats  PoolStats
+
+	// poisoned sessions released to the pool that are not idle yet
+	settling int
+}
+
+// NewSessionPool creates a pool of size sessions configured by opts. Every
+// session gets the resources and the models of opts registered, and the
+// models loaded.
+func NewSessionPool(size int, opts ...PoolOption) (*SessionPool, error) {
+	if size <= 0 {
+		return nil, NewError("NewSessionPool", EINVAL)
+	}
+
+	var o poolOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	p := &SessionPool{
+		models: o.models,
+		idle:   make(chan *Session, size),
+		done:   make(chan struct{}),
+		busy:   make(map[*Session]bool, size),
+	}
+	p.stats.Size = size
+
+	for i := 0; i < size; i++ {
+		sess, err := p.newSession(&o)
+		if err != nil {
+			return nil, errors.Join(err, p.Close())
+		}
+		p.idle <- sess
+	}
+
+	return p, nil
+}
+
+func (p *SessionPool) newSession(o *poolOptions) (*Session, error) {
+	sess, err := NewSession(o.sessOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, res := range o.resources {
+		if err := sess.RegisterErr(res); err != nil {
+			return nil, errors.Join(err, sess.Close())
+		}
+	}
+
+	for i, m := range o.models {
+		err := sess.RegisterErr(m.res)
+		if err == nil && m.loader.Load != nil {
+			err = m.loader.Load(sess, m.res)
+		}
+		if err != nil {
+			return nil, errors.Join(err, p.closeSession(sess, o.models[:i]))
+		}
+	}
+
+	return sess, nil
+}
+
+// closeSession unloads models from sess and closes it.
+func (p *SessionPool) closeSession(sess *Session, models []poolModel) error {
+	sess.waitSettled()
+
+	var errs []error
+	for i := len(models) - 1; i >= 0; i-- {
+		if models[i].loader.Unload != nil {
+			errs = append(errs, models[i].loader.Unload(sess, models[i].res))
+		}
+	}
+	errs = append(errs, sess.Close())
+
+	return errors.Join(errs...)
+}
+
+// Acquire returns an idle session of the pool, waiting for one to be
+// released if there is none, until ctx is done. The session must be handed
+// back with Release.
+func (p *SessionPool) Acquire(ctx context.Context) (*Session, error) {
+	if ctx == nil {
+		return nil, NewError("SessionPool.Acquire", EINVAL)
+	}
+
+	var sess *Session
+	var waited time.Duration
+
+	select {
+	case sess = <-p.idle:
+	case <-p.done:
+	default:
+		start := time.Now()
+		select {
+		case sess = <-p.idle:
+		case <-p.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		waited = time.Since(start)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		err := error(&Error{Code: EINVAL, Op: "SessionPool.Acquire", Msg: "pool is closed"})
+		if sess != nil {
+			err = errors.Join(err, p.closeSession(sess, p.models))
+		}
+		return nil, err
+	}
+
+	p.busy[sess] = true
+	p.stats.Acquires++
+	if waited > 0 {
+		p.stats.Waits++
+		p.stats.WaitTime += waited
+		p.stats.MaxWait = max(p.stats.MaxWait, waited)
+	}
+
+	return sess, nil
+}
+
+// Release hands sess back to the pool. A session with an abandoned call in
+// flight (see Session.Poisoned) becomes idle once the call returns. If the
+// pool has been closed, sess is closed instead.
+func (p *SessionPool) Release(sess *Session) error {
+	p.mu.Lock()
+	if !p.busy[sess] {
+		p.mu.Unlock()
+		return &Error{Code: EINVAL, Op: "SessionPool.Release", Msg: "session is not acquired from the pool"}
+	}
+	delete(p.busy, sess)
+	poisoned := sess.Poisoned()
+	if poisoned {
+		p.settling++
+	}
+	p.mu.Unlock()
+
+	if poisoned {
+		go func() {
+			sess.waitSettled()
+			p.mu.Lock()
+			p.settling--
+			p.mu.Unlock()
+			p.putIdle(sess)
+		}()
+		return nil
+	}
+
+	return p.putIdle(sess)
+}
+
+// putIdle makes sess available to Acquire, or closes it if the pool has been
+// closed.
+func (p *SessionPool) putIdle(sess *Session) error {
+	p.mu.Lock()
+	closed := p.closed
+	if !closed {
+		p.idle <- sess
+	}
+	p.mu.Unlock()
+
+	if closed {
+		return p.closeSession(sess, p.models)
+	}
+	return nil
+}
+
+// Stats returns statistics of the pool.
+func (p *SessionPool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := p.stats
+	stats.Idle = len(p.idle)
+	stats.Busy = len(p.busy)
+	stats.Settling = p.settling
+
+	return stats
+}
+
+// Close closes the idle sessions of the pool, unloading their models. Busy
+// sessions are closed when they are released. Calling Close more than once
+// is a no-op.
+func (p *SessionPool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+	p.mu.Unlock()
+
+	var errs []error
+	for {
+		select {
+		case sess := <-p.idle:
+			errs = append(errs, p.closeSession(sess, p.models))
+		default:
+			return errors.Join(errs...)
+		}
+	}
+}
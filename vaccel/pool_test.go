@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestPool(t *testing.T, size int) *SessionPool {
+	t.Helper()
+
+	p, err := NewSessionPool(size)
+	if err != nil {
+		t.Skipf("can not create pool: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := p.Close(); err != nil {
+			t.Errorf("SessionPool.Close: %v", err)
+		}
+	})
+	return p
+}
+
+func TestSessionPoolAcquire(t *testing.T) {
+	p := newTestPool(t, 1)
+
+	//nolint:staticcheck // a nil context is rejected
+	if _, err := p.Acquire(nil); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Acquire(nil) = %v, want ErrInvalid", err)
+	}
+
+	sess, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Acquire with no idle session = %v, want context.DeadlineExceeded", err)
+	}
+
+	acquired := make(chan *Session)
+	go func() {
+		s, err := p.Acquire(context.Background())
+		if err != nil {
+			t.Errorf("waiting Acquire: %v", err)
+		}
+		acquired <- s
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if err := p.Release(sess); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if s := <-acquired; s != sess {
+		t.Fatalf("waiting Acquire got %p, want the released session %p", s, sess)
+	}
+
+	stats := p.Stats()
+	if stats.Size != 1 || stats.Idle != 0 || stats.Busy != 1 || stats.Acquires != 2 || stats.Waits != 1 {
+		t.Errorf("Stats = %+v, want 1 busy session, 2 acquires and 1 wait", stats)
+	}
+	if stats.WaitTime <= 0 || stats.MaxWait != stats.WaitTime {
+		t.Errorf("Stats = %+v, want the wait time of the single wait", stats)
+	}
+
+	if err := p.Release(sess); err != nil {
+		t.Errorf("Release: %v", err)
+	}
+	if err := p.Release(sess); !errors.Is(err, ErrInvalid) {
+		t.Errorf("second Release = %v, want ErrInvalid", err)
+	}
+}
+
+func TestSessionPoolReleasePoisoned(t *testing.T) {
+	p := newTestPool(t, 1)
+
+	sess, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started, unblock := make(chan struct{}), make(chan struct{})
+	go func() {
+		<-started
+		cancel()
+	}()
+	if _, err := sess.callCtx(ctx, "test", func() int {
+		close(started)
+		<-unblock
+		return OK
+	}, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("callCtx = %v, want context.Canceled", err)
+	}
+	if !sess.Poisoned() {
+		t.Fatalf("session is not poisoned")
+	}
+
+	if err := p.Release(sess); err != nil {
+		t.Fatalf("Release of a poisoned session: %v", err)
+	}
+	if stats := p.Stats(); stats.Idle != 0 || stats.Busy != 0 || stats.Settling != 1 {
+		t.Errorf("Stats = %+v, want the poisoned session settling", stats)
+	}
+
+	close(unblock)
+	acquireCtx, cancelAcquire := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelAcquire()
+	s, err := p.Acquire(acquireCtx)
+	if err != nil {
+		t.Fatalf("Acquire after the abandoned call returned: %v", err)
+	}
+	if s != sess || s.Poisoned() {
+		t.Errorf("Acquire got %p (poisoned %v), want the settled session %p", s, s.Poisoned(), sess)
+	}
+	if stats := p.Stats(); stats.Size != 1 || stats.Busy != 1 || stats.Settling != 0 {
+		t.Errorf("Stats = %+v, want the settled session busy", stats)
+	}
+	p.Release(s)
+}
+
+func TestSessionPoolCloseBusy(t *testing.T) {
+	p, err := NewSessionPool(2)
+	if err != nil {
+		t.Skipf("can not create pool: %v", err)
+	}
+
+	sess, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if stats := p.Stats(); stats.Idle != 0 || stats.Busy != 1 {
+		t.Errorf("Stats after Close = %+v, want only the busy session", stats)
+	}
+	if sess.cSess == nil {
+		t.Errorf("Close closed a busy session")
+	}
+	if _, err := p.Acquire(context.Background()); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Acquire from a closed pool = %v, want ErrInvalid", err)
+	}
+
+	if err := p.Release(sess); err != nil {
+		t.Errorf("Release to a closed pool: %v", err)
+	}
+	if sess.cSess != nil {
+		t.Errorf("Release to a closed pool did not close the session")
+	}
+	if err := p.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
@@ -4,7 +4,11 @@ package vaccel
 
 // #include <vaccel/resource.h>
 import "C"
-import "unsafe"
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+)
 
 type ResourceType int
 
@@ -14,16 +18,43 @@ const (
 	ResourceModel
 )
 
+// Resource is a vAccel resource. It must not be copied; use a *Resource.
 type Resource struct {
+	noCopy noCopy
+
 	cRes *C.struct_vaccel_resource
+	h    *handle
+
+	// whether the blobs are mappings of files, see NewResourceFromMmap
+	mapped bool
+}
+
+// ref returns a new Resource that shares the C resource and the handle of
+// r, for calls that outlive their context and must not see the fields of r
+// being cleared.
+func (r *Resource) ref() *Resource {
+	return &Resource{cRes: r.cRes, h: r.h, mapped: r.mapped}
 }
 
 func (t ResourceType) ToCEnum() C.vaccel_resource_type_t {
 	return C.vaccel_resource_type_t(t)
 }
 
+func (t ResourceType) String() string {
+	switch t {
+	case ResourceLib:
+		return "lib"
+	case ResourceData:
+		return "data"
+	case ResourceModel:
+		return "model"
+	default:
+		return fmt.Sprintf("ResourceType(%d)", int(t))
+	}
+}
+
 func (r *Resource) Init(path string, resType ResourceType) int {
-	return int(C.vaccel_resource_new(&r.cRes, C.CString(path), resType.ToCEnum()))
+	return r.track(int(C.vaccel_resource_new(&r.cRes, C.CString(path), resType.ToCEnum())))
 }
 
 func (r *Resource) InitMulti(paths []string, resType ResourceType) int {
@@ -43,7 +74,7 @@ func (r *Resource) InitMulti(paths []string, resType ResourceType) int {
 
 		pathSlice[i] = str
 	}
-	return int(C.vaccel_resource_multi_new(&r.cRes, cPathsPtr, cNrPaths, resType.ToCEnum()))
+	return r.track(int(C.vaccel_resource_multi_new(&r.cRes, cPathsPtr, cNrPaths, resType.ToCEnum())))
 }
 
 func (r *Resource) InitFromBuf(bytes []byte, resType ResourceType, filename string, memOnly bool) int {
@@ -59,7 +90,7 @@ func (r *Resource) InitFromBuf(bytes []byte, resType ResourceType, filename stri
 		defer C.free(unsafe.Pointer(cfname))
 	}
 
-	return int(C.vaccel_resource_from_buf(&r.cRes, cResBuf, cResLen, resType.ToCEnum(), cfname, C.bool(memOnly)))
+	return r.track(int(C.vaccel_resource_from_buf(&r.cRes, cResBuf, cResLen, resType.ToCEnum(), cfname, C.bool(memOnly))))
 }
 
 func (r *Resource) InitFromBlobs(blobs []Blob, resType ResourceType) int {
@@ -77,17 +108,147 @@ func (r *Resource) InitFromBlobs(blobs []Blob, resType ResourceType) int {
 		blobSlice[i] = blobs[i].cBlob
 	}
 
-	return int(C.vaccel_resource_from_blobs(&r.cRes, cBlobsPtr, cNrBlobs, resType.ToCEnum()))
+	ret := int(C.vaccel_resource_from_blobs(&r.cRes, cBlobsPtr, cNrBlobs, resType.ToCEnum()))
+	runtime.KeepAlive(blobs)
+	return r.track(ret)
+}
+
+// track attaches a handle to a newly created C resource so it is released
+// exactly once.
+func (r *Resource) track(ret int) int {
+	if ret != OK {
+		return ret
+	}
+
+	cRes := r.cRes
+	r.h = newHandle("Resource", func() int {
+		return int(C.vaccel_resource_delete(cRes))
+	})
+
+	return OK
 }
 
 func (r *Resource) Release() int {
-	return int(C.vaccel_resource_delete(r.cRes))
+	if r.h == nil {
+		return EINVAL
+	}
+	ret := r.h.close()
+	if ret == OK {
+		r.cRes = nil
+	}
+	return ret
+}
+
+// Close releases the resource. It implements io.Closer and, unlike Release,
+// it is a no-op for a resource that was never initialized.
+func (r *Resource) Close() error {
+	if r.h == nil {
+		return nil
+	}
+	return NewError("vaccel_resource_delete", r.Release())
 }
 
 func (r *Resource) GetID() int64 {
+	if r == nil || r.cRes == nil {
+		return -1
+	}
+	defer runtime.KeepAlive(r)
 	return int64(r.cRes.id)
 }
 
+// Type returns the type of the resource.
+func (r *Resource) Type() ResourceType {
+	defer runtime.KeepAlive(r)
+	if r == nil || r.cRes == nil {
+		return -1
+	}
+	return ResourceType(r.cRes._type)
+}
+
+// Paths returns the paths the resource was created from. Resources created
+// from buffers or blobs have the paths of the files vAccel created for them,
+// if any.
+func (r *Resource) Paths() []string {
+	defer runtime.KeepAlive(r)
+	if r == nil || r.cRes == nil {
+		return nil
+	}
+
+	n := int(r.cRes.nr_paths)
+	if n == 0 || r.cRes.paths == nil {
+		return nil
+	}
+
+	cPaths := unsafe.Slice(r.cRes.paths, n)
+	paths := make([]string, n)
+	for i := range cPaths {
+		paths[i] = C.GoString(cPaths[i])
+	}
+	return paths
+}
+
+// Blobs returns the blobs of the resource. The blobs are owned by the
+// resource, which they keep alive, and are valid until it is released. They
+// are released along with the resource: Release returns EINVAL and Close is a
+// no-op for them.
+func (r *Resource) Blobs() []*Blob {
+	defer runtime.KeepAlive(r)
+	if r == nil || r.cRes == nil {
+		return nil
+	}
+
+	n := int(r.cRes.nr_blobs)
+	if n == 0 || r.cRes.blobs == nil {
+		return nil
+	}
+
+	cBlobs := unsafe.Slice(r.cRes.blobs, n)
+	blobs := make([]*Blob, n)
+	for i := range cBlobs {
+		blobs[i] = &Blob{cBlob: cBlobs[i], res: r, mapped: r.mapped}
+	}
+	return blobs
+}
+
+// RunDir returns the directory vAccel stores the files of the resource in,
+// or an empty string if there is none.
+func (r *Resource) RunDir() string {
+	defer runtime.KeepAlive(r)
+	if r == nil || r.cRes == nil || r.cRes.rundir == nil {
+		return ""
+	}
+	return C.GoString(r.cRes.rundir)
+}
+
 func (r *Resource) GetRefcount() uint32 {
+	defer runtime.KeepAlive(r)
 	return uint32(C.vaccel_resource_refcount(r.cRes))
 }
+
+// InitErr is like Init but returns an error.
+func (r *Resource) InitErr(path string, resType ResourceType) error {
+	return NewError("vaccel_resource_new", r.Init(path, resType))
+}
+
+// InitMultiErr is like InitMulti but returns an error.
+func (r *Resource) InitMultiErr(paths []string, resType ResourceType) error {
+	return NewError("vaccel_resource_multi_new", r.InitMulti(paths, resType))
+}
+
+// InitFromBufErr is like InitFromBuf but returns an error.
+func (r *Resource) InitFromBufErr(bytes []byte, resType ResourceType, filename string, memOnly bool) error {
+	if len(bytes) == 0 {
+		return NewError("vaccel_resource_from_buf", EINVAL)
+	}
+	return NewError("vaccel_resource_from_buf", r.InitFromBuf(bytes, resType, filename, memOnly))
+}
+
+// InitFromBlobsErr is like InitFromBlobs but returns an error.
+func (r *Resource) InitFromBlobsErr(blobs []Blob, resType ResourceType) error {
+	return NewError("vaccel_resource_from_blobs", r.InitFromBlobs(blobs, resType))
+}
+
+// ReleaseErr is like Release but returns an error.
+func (r *Resource) ReleaseErr() error {
+	return NewError("vaccel_resource_delete", r.Release())
+}
@@ -0,0 +1,240 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+// #include <stdlib.h>
+import "C"
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"unsafe"
+)
+
+// readChunk is the initial size of the C buffer a stream of unknown size is
+// read into.
+const readChunk = 64 << 10
+
+// sizeHint returns the size of the data r will return, if r can report it,
+// or -1.
+func sizeHint(r io.Reader) int64 {
+	switch v := r.(type) {
+	case interface{ Stat() (fs.FileInfo, error) }:
+		if fi, err := v.Stat(); err == nil && fi.Mode().IsRegular() {
+			return fi.Size()
+		}
+	case interface{ Len() int }:
+		return int64(v.Len())
+	}
+	return -1
+}
+
+// readToC reads r until EOF into a buffer allocated with C.malloc, so the
+// data is never held in Go memory. size is used as the initial size of the
+// buffer if it is positive. The caller must free the returned buffer.
+func readToC(r io.Reader, size int64) (unsafe.Pointer, int, error) {
+	capacity := readChunk
+	if size > 0 {
+		capacity = int(size)
+	}
+
+	buf := C.malloc(C.size_t(capacity))
+	if buf == nil {
+		return nil, 0, NewError("readToC", ENOMEM)
+	}
+
+	n := 0
+	for {
+		if n == capacity {
+			/* Only grow the buffer if there is more data to read */
+			var probe [1]byte
+			m, err := io.ReadFull(r, probe[:])
+			if m == 0 {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				C.free(buf)
+				return nil, 0, err
+			}
+
+			capacity *= 2
+			newBuf := C.realloc(buf, C.size_t(capacity))
+			if newBuf == nil {
+				C.free(buf)
+				return nil, 0, NewError("readToC", ENOMEM)
+			}
+			buf = newBuf
+
+			unsafe.Slice((*byte)(buf), capacity)[n] = probe[0]
+			n++
+			continue
+		}
+
+		m, err := r.Read(unsafe.Slice((*byte)(buf), capacity)[n:])
+		n += m
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			C.free(buf)
+			return nil, 0, err
+		}
+	}
+
+	return buf, n, nil
+}
+
+// newBlobFromReader streams r into C memory and creates a buffer blob named
+// name that references it, so the data is held in memory only once. The
+// memory is freed along with the blob.
+func newBlobFromReader(r io.Reader, size int64, name string) (*Blob, error) {
+	buf, n, err := readToC(r, size)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		C.free(buf)
+		return nil, &Error{Code: EINVAL, Op: "vaccel_blob_from_buf", Msg: "no data for blob " + name}
+	}
+
+	b := new(Blob)
+	data := unsafe.Slice((*byte)(buf), n)
+	if err := b.InitFromBufErr(data, false, name, "", false); err != nil {
+		C.free(buf)
+		return nil, err
+	}
+	b.h.chain(func() {
+		C.free(buf)
+	})
+
+	return b, nil
+}
+
+// newResourceFromOwnedBlobs creates a resource from blobs. The blobs are
+// released along with the resource, or right away if it can not be created.
+func newResourceFromOwnedBlobs(blobs []*Blob, typ ResourceType) (*Resource, error) {
+	vals := make([]Blob, len(blobs))
+	for i, b := range blobs {
+		vals[i] = *b
+	}
+
+	r := new(Resource)
+	if err := r.InitFromBlobsErr(vals, typ); err != nil {
+		releaseBlobs(blobs)
+		return nil, err
+	}
+	r.h.chain(func() {
+		releaseBlobs(blobs)
+	})
+
+	return r, nil
+}
+
+func releaseBlobs(blobs []*Blob) {
+	for _, b := range blobs {
+		b.Release()
+	}
+}
+
+// NewResourceFromReader creates a resource of type typ from the data read
+// from r. The data is streamed into memory allocated by C and handed to
+// vAccel as a blob named name, so it is never held twice in memory.
+func NewResourceFromReader(r io.Reader, typ ResourceType, name string) (*Resource, error) {
+	if r == nil || name == "" {
+		return nil, NewError("NewResourceFromReader", EINVAL)
+	}
+
+	b, err := newBlobFromReader(r, sizeHint(r), name)
+	if err != nil {
+		return nil, err
+	}
+
+	return newResourceFromOwnedBlobs([]*Blob{b}, typ)
+}
+
+// newBlobFromFS streams the file at p of fsys into a blob named name.
+func newBlobFromFS(fsys fs.FS, p string, name string) (*Blob, error) {
+	f, err := fsys.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return newBlobFromReader(f, sizeHint(f), name)
+}
+
+// NewResourceFromFS creates a resource of type typ from the files at paths
+// of fsys, which can be an embed.FS. Each file becomes a blob named after the
+// base name of its path, so the base names must be unique. Files are
+// streamed like in NewResourceFromReader.
+func NewResourceFromFS(fsys fs.FS, typ ResourceType, paths ...string) (*Resource, error) {
+	if fsys == nil || len(paths) == 0 {
+		return nil, NewError("NewResourceFromFS", EINVAL)
+	}
+
+	names := make(map[string]bool, len(paths))
+	blobs := make([]*Blob, 0, len(paths))
+	for _, p := range paths {
+		name := path.Base(p)
+		if names[name] {
+			releaseBlobs(blobs)
+			return nil, &Error{Code: EEXIST, Op: "NewResourceFromFS", Msg: "duplicate file name " + name}
+		}
+		names[name] = true
+
+		b, err := newBlobFromFS(fsys, p, name)
+		if err != nil {
+			releaseBlobs(blobs)
+			return nil, err
+		}
+		blobs = append(blobs, b)
+	}
+
+	return newResourceFromOwnedBlobs(blobs, typ)
+}
+
+// NewResourceFromFSDir creates a resource of type typ from all the regular
+// files under dir of fsys, which can be an embed.FS. vAccel blobs can not
+// have directories in their names, so each file becomes a blob named after
+// the base name of its path, like in NewResourceFromFS, and the base names
+// of all the files under dir must be unique.
+func NewResourceFromFSDir(fsys fs.FS, typ ResourceType, dir string) (*Resource, error) {
+	if fsys == nil {
+		return nil, NewError("NewResourceFromFSDir", EINVAL)
+	}
+
+	names := make(map[string]bool)
+	var blobs []*Blob
+	err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		name := path.Base(p)
+		if names[name] {
+			return &Error{Code: EEXIST, Op: "NewResourceFromFSDir", Msg: "duplicate file name " + name}
+		}
+		names[name] = true
+
+		b, err := newBlobFromFS(fsys, p, name)
+		if err != nil {
+			return err
+		}
+		blobs = append(blobs, b)
+
+		return nil
+	})
+	if err != nil {
+		releaseBlobs(blobs)
+		return nil, err
+	}
+	if len(blobs) == 0 {
+		return nil, &Error{Code: ENOENT, Op: "NewResourceFromFSDir", Msg: "no files in " + dir}
+	}
+
+	return newResourceFromOwnedBlobs(blobs, typ)
+}
@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+/* test files can not use cgo, so the C buffers are checked through blobs */
+func TestReadToC(t *testing.T) {
+	data := bytes.Repeat([]byte("vaccel"), readChunk/3)
+
+	for _, tc := range []struct {
+		name string
+		size int64
+	}{
+		{"unknown size", -1},
+		{"exact size", int64(len(data))},
+		{"short size", 7},
+		{"long size", int64(2 * len(data))},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := newBlobFromReader(bytes.NewReader(data), tc.size, "data")
+			if err != nil {
+				t.Skipf("can not create blob: %v", err)
+			}
+			defer b.Close()
+
+			got, err := b.Bytes()
+			if err != nil {
+				t.Fatalf("Blob.Bytes: %v", err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("read %d bytes, want the %d bytes of the reader", len(got), len(data))
+			}
+		})
+	}
+
+	if _, err := newBlobFromReader(bytes.NewReader(nil), -1, "empty"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("empty reader: got %v, want ErrInvalid", err)
+	}
+
+	failing := io.MultiReader(bytes.NewReader(data[:10]), errReader{})
+	if _, _, err := readToC(failing, 10); !errors.Is(err, errTestRead) {
+		t.Errorf("failing reader: got %v, want %v", err, errTestRead)
+	}
+}
+
+var errTestRead = errors.New("read failed")
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errTestRead }
+
+func TestNewResourceFromFSDir(t *testing.T) {
+	fsys := fstest.MapFS{
+		"model/saved_model.pb":              {Data: []byte("graph")},
+		"model/variables/variables.index":   {Data: []byte("index")},
+		"model/variables/variables.data-00": {Data: []byte("data")},
+		"dup/a/file":                        {Data: []byte("a")},
+		"dup/b/file":                        {Data: []byte("b")},
+		"empty/sub":                         {Mode: fs.ModeDir},
+	}
+
+	res, err := NewResourceFromFSDir(fsys, ResourceModel, "model")
+	if err != nil {
+		t.Skipf("can not create resource: %v", err)
+	}
+	defer res.Close()
+
+	var names []string
+	for _, b := range res.Blobs() {
+		names = append(names, b.Name())
+	}
+	slices.Sort(names)
+	want := []string{"saved_model.pb", "variables.data-00", "variables.index"}
+	if len(names) > 0 && !slices.Equal(names, want) {
+		t.Errorf("blob names = %q, want %q", names, want)
+	}
+
+	if _, err := NewResourceFromFSDir(fsys, ResourceModel, "dup"); !errors.Is(err, ErrExist) {
+		t.Errorf("duplicate base names: got %v, want ErrExist", err)
+	}
+	if _, err := NewResourceFromFSDir(fsys, ResourceModel, "empty"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("no regular files: got %v, want ErrNotExist", err)
+	}
+	if _, err := NewResourceFromFSDir(fsys, ResourceModel, "missing"); err == nil {
+		t.Error("missing directory: got nil error")
+	}
+	if _, err := NewResourceFromFSDir(nil, ResourceModel, "."); !errors.Is(err, ErrInvalid) {
+		t.Errorf("nil fs: got %v, want ErrInvalid", err)
+	}
+}
@@ -0,0 +1,214 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"bytes"
+	"cmp"
+	"debug/elf"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxSymbolSuggestions is the number of close matches a missing symbol error
+// suggests.
+const maxSymbolSuggestions = 3
+
+// ExportedSymbols returns the sorted names of the functions and objects the
+// files of a ResourceLib resource export. The files are read from the blobs
+// of the resource or, if it has none, from its paths.
+func (r *Resource) ExportedSymbols() ([]string, error) {
+	const op = "Resource.ExportedSymbols"
+
+	if r == nil || r.cRes == nil {
+		return nil, NewError(op, EINVAL)
+	}
+	if t := r.Type(); t != ResourceLib {
+		return nil, &Error{Code: EINVAL, Op: op,
+			Msg: fmt.Sprintf("resource of type %v is not a library", t)}
+	}
+
+	var syms []string
+	blobs := r.Blobs()
+	if len(blobs) > 0 {
+		for _, b := range blobs {
+			data, err := b.Bytes()
+			if err != nil {
+				return nil, symbolFileError(op, b.Name(), err)
+			}
+			s, err := elfExports(data)
+			if err != nil {
+				return nil, symbolFileError(op, b.Name(), err)
+			}
+			syms = append(syms, s...)
+		}
+	} else {
+		paths := r.Paths()
+		if len(paths) == 0 {
+			return nil, &Error{Code: ENOENT, Op: op, Msg: "resource has no files"}
+		}
+		for _, p := range paths {
+			f, err := elf.Open(p)
+			if err != nil {
+				return nil, symbolFileError(op, p, err)
+			}
+			s, err := elfFileExports(f)
+			f.Close()
+			if err != nil {
+				return nil, symbolFileError(op, p, err)
+			}
+			syms = append(syms, s...)
+		}
+	}
+
+	slices.Sort(syms)
+	return slices.Compact(syms), nil
+}
+
+// HasSymbol reports whether a file of a ResourceLib resource exports name.
+func (r *Resource) HasSymbol(name string) (bool, error) {
+	syms, err := r.ExportedSymbols()
+	if err != nil {
+		return false, err
+	}
+	_, found := slices.BinarySearch(syms, name)
+	return found, nil
+}
+
+// checkSymbol returns an ENOENT error that suggests close matches if res
+// does not export name.
+func checkSymbol(op string, res *Resource, name string) error {
+	syms, err := res.ExportedSymbols()
+	if err != nil {
+		return err
+	}
+	if _, found := slices.BinarySearch(syms, name); found {
+		return nil
+	}
+
+	msg := fmt.Sprintf("symbol %q not found in resource", name)
+	if s := closeSymbols(name, syms); len(s) > 0 {
+		quoted := make([]string, len(s))
+		for i := range s {
+			quoted[i] = strconv.Quote(s[i])
+		}
+		msg += "; did you mean " + strings.Join(quoted, " or ") + "?"
+	}
+	return &Error{Code: ENOENT, Op: op, Msg: msg}
+}
+
+// closeSymbols returns the symbols of syms within a small edit distance of
+// name, closest first.
+func closeSymbols(name string, syms []string) []string {
+	type match struct {
+		sym  string
+		dist int
+	}
+
+	limit := max(2, len(name)/3)
+	var matches []match
+	for _, s := range syms {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(s)); d <= limit {
+			matches = append(matches, match{s, d})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b match) int {
+		return cmp.Compare(a.dist, b.dist)
+	})
+
+	var out []string
+	for _, m := range matches[:min(len(matches), maxSymbolSuggestions)] {
+		out = append(out, m.sym)
+	}
+	return out
+}
+
+// editDistance returns the Levenshtein distance of a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func elfExports(data []byte) ([]string, error) {
+	f, err := elf.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return elfFileExports(f)
+}
+
+// elfFileExports returns the defined global functions and objects of the
+// dynamic symbol table of f, or of its symbol table if it has no dynamic one.
+func elfFileExports(f *elf.File) ([]string, error) {
+	syms, err := f.DynamicSymbols()
+	if errors.Is(err, elf.ErrNoSymbols) {
+		syms, err = f.Symbols()
+	}
+	if err != nil && !errors.Is(err, elf.ErrNoSymbols) {
+		return nil, err
+	}
+
+	var names []string
+	for _, s := range syms {
+		if s.Name == "" || s.Section == elf.SHN_UNDEF {
+			continue
+		}
+		switch elf.ST_BIND(s.Info) {
+		case elf.STB_GLOBAL, elf.STB_WEAK:
+		default:
+			continue
+		}
+		switch elf.ST_TYPE(s.Info) {
+		case elf.STT_FUNC, elf.STT_OBJECT, elf.STT_LOOS: /* STT_GNU_IFUNC */
+		default:
+			continue
+		}
+		switch elf.ST_VISIBILITY(s.Other) {
+		case elf.STV_DEFAULT, elf.STV_PROTECTED:
+		default:
+			continue
+		}
+		names = append(names, s.Name)
+	}
+	return names, nil
+}
+
+// symbolFileError converts an error reading the file name of a resource to a
+// vAccel error.
+func symbolFileError(op string, name string, err error) error {
+	var vErr *Error
+	if errors.As(err, &vErr) {
+		return err
+	}
+
+	/* anything but a failure to read the file is a malformed ELF */
+	code := ELIBBAD
+	var pathErr *fs.PathError
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		code = ENOENT
+	case errors.As(err, &pathErr):
+		code = EIO
+	}
+	return &Error{Code: code, Op: op, Msg: fmt.Sprintf("%s: %v", name, err)}
+}
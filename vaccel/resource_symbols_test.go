@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+/* cgo binaries, like the test binary, export the cgo runtime functions */
+const testExportedSymbol = "crosscall2"
+
+func newTestExecutableResource(t *testing.T, typ ResourceType) *Resource {
+	t.Helper()
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skipf("can not find test executable: %v", err)
+	}
+
+	var res Resource
+	if err := res.InitErr(exe, typ); err != nil {
+		t.Skipf("can not create resource: %v", err)
+	}
+	t.Cleanup(func() { res.Close() })
+	if len(res.Paths()) == 0 {
+		t.Skip("resource does not report its paths")
+	}
+	return &res
+}
+
+func TestResourceSymbols(t *testing.T) {
+	res := newTestExecutableResource(t, ResourceLib)
+
+	syms, err := res.ExportedSymbols()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.IsSorted(syms) {
+		t.Error("symbols are not sorted")
+	}
+	if ok, err := res.HasSymbol(testExportedSymbol); err != nil || !ok {
+		t.Errorf("HasSymbol(%q) = %v, %v, want true", testExportedSymbol, ok, err)
+	}
+	if ok, err := res.HasSymbol("malloc"); err != nil || ok {
+		t.Errorf("HasSymbol(%q) = %v, %v, want false", "malloc", ok, err)
+	}
+
+	data := newTestExecutableResource(t, ResourceData)
+	if _, err := data.ExportedSymbols(); !errors.Is(err, ErrInvalid) {
+		t.Errorf("data resource: got %v, want %v", err, ErrInvalid)
+	}
+
+	bad := newTestLibResource(t)
+	if len(bad.Paths()) > 0 {
+		if _, err := bad.ExportedSymbols(); !errors.Is(err, ErrLibBad) {
+			t.Errorf("invalid ELF: got %v, want %v", err, ErrLibBad)
+		}
+	}
+}
+
+func TestCloseSymbols(t *testing.T) {
+	syms := []string{"mytestfunc", "mytestfunc_nonser", "other", "MyTestFunc2"}
+
+	got := closeSymbols("mytestfnc", syms)
+	want := []string{"mytestfunc", "MyTestFunc2"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := closeSymbols("unrelated", syms); len(got) != 0 {
+		t.Errorf("got %q, want none", got)
+	}
+}
+
+func TestExecSymbolCheck(t *testing.T) {
+	sess := newTestSession(t)
+	res := newTestExecutableResource(t, ResourceLib)
+
+	err := ExecWithResourceArgs(sess, res, "crosscal2", nil, nil, WithSymbolCheck())
+	if !errors.Is(err, ErrNotExist) {
+		t.Fatalf("got %v, want %v", err, ErrNotExist)
+	}
+	if !strings.Contains(err.Error(), `"`+testExportedSymbol+`"`) {
+		t.Errorf("error %q does not suggest %q", err, testExportedSymbol)
+	}
+
+	if err := checkExec("exec", res, testExportedSymbol, []ExecOption{WithSymbolCheck()}); err != nil {
+		t.Errorf("exported symbol: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestResourceBlobs(t *testing.T) {
+	data := []byte("vaccel")
+	res, err := NewResourceFromReader(bytes.NewReader(data), ResourceData, "data")
+	if err != nil {
+		t.Skipf("can not create resource: %v", err)
+	}
+
+	blobs := res.Blobs()
+	if len(blobs) != 1 {
+		res.Close()
+		t.Skipf("resource reports %d blobs", len(blobs))
+	}
+	b := blobs[0]
+	if b.res != res {
+		t.Errorf("blob is not owned by the resource")
+	}
+	if got, err := b.Bytes(); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("Bytes = %q, %v, want %q", got, err, data)
+	}
+
+	if ret := b.Release(); ret != EINVAL {
+		t.Errorf("Release of a resource blob = %d, want EINVAL", ret)
+	}
+	if err := b.Close(); err != nil {
+		t.Errorf("Close of a resource blob: %v", err)
+	}
+	if b.Name() != "data" {
+		t.Errorf("Name after Release and Close = %q, want %q", b.Name(), "data")
+	}
+
+	if err := res.Close(); err != nil {
+		t.Fatalf("Resource.Close: %v", err)
+	}
+	if b.Name() != "" || b.Size() != 0 {
+		t.Errorf("blob of a released resource reports %q of size %d", b.Name(), b.Size())
+	}
+	if _, err := b.Bytes(); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Bytes of a blob of a released resource = %v, want ErrInvalid", err)
+	}
+}
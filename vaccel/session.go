@@ -4,35 +4,325 @@ package vaccel
 
 // #include <vaccel/session.h>
 import "C"
+import (
+	"context"
+	"errors"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
 
+// Session is a vAccel session. The bookkeeping of a session, like its
+// registered resources, is safe for concurrent use, but vAccel does not
+// guarantee that operations can run concurrently on the same session. Use a
+// separate session per goroutine, e.g. through a SessionPool, to run
+// operations in parallel. A Session must not be copied; use a *Session.
 type Session struct {
 	cSess *C.struct_vaccel_session
+
+	// number of calls abandoned by their context that are still running
+	abandoned atomic.Int32
+	settled   sync.WaitGroup
+
+	h *handle
+
+	// plugin required with WithPlugin, if any
+	plugin string
+
+	// resources registered through the session, in registration order
+	mu        sync.Mutex
+	resources []*Resource
+}
+
+// ResourceInfo describes a resource registered with a session.
+type ResourceInfo struct {
+	Resource *Resource
+	ID       int64
+	Type     ResourceType
+	Paths    []string
 }
 
 func (s *Session) Init(flags uint32) int {
-	return int(C.vaccel_session_new(&s.cSess, C.uint32_t(flags)))
+	ret := int(C.vaccel_session_new(&s.cSess, C.uint32_t(flags)))
+	if ret != OK {
+		return ret
+	}
+
+	cSess := s.cSess
+	s.h = newHandle("Session", func() int {
+		return int(C.vaccel_session_delete(cSess))
+	})
+
+	return OK
 }
 
 func (s *Session) Release() int {
-	return int(C.vaccel_session_delete(s.cSess))
+	if s.h == nil {
+		return EINVAL
+	}
+	if s.Poisoned() {
+		return EBUSY
+	}
+	ret := s.h.close()
+	if ret == OK {
+		s.cSess = nil
+
+		s.mu.Lock()
+		s.resources = nil
+		s.mu.Unlock()
+	}
+	return ret
+}
+
+// Close unregisters all the resources registered through the session, in
+// reverse registration order, and releases the session. It implements
+// io.Closer and, unlike Release, it is a no-op for a session that was never
+// initialized. Resources are only unregistered, so they can still be used by
+// other sessions and must be released by their owner.
+func (s *Session) Close() error {
+	if s.h == nil {
+		return nil
+	}
+	if s.Poisoned() {
+		return NewError("vaccel_session_delete", EBUSY)
+	}
+
+	s.mu.Lock()
+	resources := slices.Clone(s.resources)
+	s.mu.Unlock()
+
+	var errs []error
+	for i := len(resources) - 1; i >= 0; i-- {
+		/* released by its owner before the session was closed */
+		if resources[i].cRes == nil {
+			continue
+		}
+		if err := s.UnregisterErr(resources[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	errs = append(errs, NewError("vaccel_session_delete", s.Release()))
+
+	return errors.Join(errs...)
 }
 
 func (s *Session) Register(r *Resource) int {
-	return int(C.vaccel_resource_register(r.cRes, s.cSess))
+	if s.cSess == nil || r == nil || r.cRes == nil {
+		return EINVAL
+	}
+	ret := int(C.vaccel_resource_register(r.cRes, s.cSess))
+	runtime.KeepAlive(s)
+	runtime.KeepAlive(r)
+	if ret == OK {
+		s.mu.Lock()
+		s.resources = append(s.resources, r)
+		s.mu.Unlock()
+	}
+	return ret
 }
 
 func (s *Session) Unregister(r *Resource) int {
-	return int(C.vaccel_resource_unregister(r.cRes, s.cSess))
+	if s.cSess == nil || r == nil || r.cRes == nil {
+		return EINVAL
+	}
+	ret := int(C.vaccel_resource_unregister(r.cRes, s.cSess))
+	runtime.KeepAlive(s)
+	runtime.KeepAlive(r)
+	if ret == OK {
+		s.mu.Lock()
+		s.resources = slices.DeleteFunc(s.resources, func(res *Resource) bool {
+			return res.cRes == r.cRes
+		})
+		s.mu.Unlock()
+	}
+	return ret
+}
+
+// IsRegistered reports whether r has been registered through the session.
+func (s *Session) IsRegistered(r *Resource) bool {
+	if r == nil || r.cRes == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.ContainsFunc(s.resources, func(res *Resource) bool {
+		return res.cRes == r.cRes
+	})
+}
+
+// Resources returns the resources registered through the session, in
+// registration order. Resources released by their owner while registered
+// are skipped.
+func (s *Session) Resources() []ResourceInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos := make([]ResourceInfo, 0, len(s.resources))
+	for _, r := range s.resources {
+		if r.cRes == nil {
+			continue
+		}
+		infos = append(infos, ResourceInfo{
+			Resource: r,
+			ID:       r.GetID(),
+			Type:     r.Type(),
+			Paths:    r.Paths(),
+		})
+	}
+	return infos
 }
 
 func (s *Session) GetID() int64 {
+	if s == nil || s.cSess == nil {
+		return -1
+	}
+	defer runtime.KeepAlive(s)
 	return int64(s.cSess.id)
 }
 
 func (s *Session) Update(flags uint32) int {
+	defer runtime.KeepAlive(s)
 	return int(C.vaccel_session_update(s.cSess, C.uint32_t(flags)))
 }
 
 func (s *Session) GetFlags() int32 {
+	defer runtime.KeepAlive(s)
 	return int32(s.cSess.hint)
 }
+
+// Hint returns the plugin hint of the session, or 0 if it is not
+// initialized.
+func (s *Session) Hint() SessionHint {
+	if s == nil || s.cSess == nil {
+		return 0
+	}
+	defer runtime.KeepAlive(s)
+	return SessionHint(s.cSess.hint)
+}
+
+// SetHint validates h and updates the plugin hint of the session. Whether a
+// session is local or remote is decided when it is created, so h can not
+// change it.
+func (s *Session) SetHint(h SessionHint) error {
+	if s.cSess == nil {
+		return NewError("vaccel_session_update", EINVAL)
+	}
+	if err := h.Validate(); err != nil {
+		return err
+	}
+	if h.IsRemote() != s.Hint().IsRemote() {
+		return &Error{Code: EINVAL, Op: "vaccel_session_update",
+			Msg: "a session can not switch between local and remote"}
+	}
+	return s.UpdateErr(uint32(h))
+}
+
+// Plugin returns the plugin name required with WithPlugin when the session
+// was created, or an empty string.
+func (s *Session) Plugin() string {
+	if s == nil {
+		return ""
+	}
+	return s.plugin
+}
+
+// InitErr is like Init but returns an error.
+func (s *Session) InitErr(flags uint32) error {
+	return NewError("vaccel_session_new", s.Init(flags))
+}
+
+// ReleaseErr is like Release but returns an error.
+func (s *Session) ReleaseErr() error {
+	return NewError("vaccel_session_delete", s.Release())
+}
+
+// RegisterErr is like Register but returns an error.
+func (s *Session) RegisterErr(r *Resource) error {
+	return NewError("vaccel_resource_register", s.Register(r))
+}
+
+// UnregisterErr is like Unregister but returns an error.
+func (s *Session) UnregisterErr(r *Resource) error {
+	return NewError("vaccel_resource_unregister", s.Unregister(r))
+}
+
+// UpdateErr is like Update but returns an error.
+func (s *Session) UpdateErr(flags uint32) error {
+	return NewError("vaccel_session_update", s.Update(flags))
+}
+
+// Poisoned reports whether a call started with one of the ...Ctx functions
+// was abandoned because its context was done and is still running in C.
+// While poisoned, the session can not be released, and the operations that
+// have a ...Ctx variant fail with EBUSY instead of running alongside the
+// abandoned call.
+func (s *Session) Poisoned() bool {
+	return s != nil && s.abandoned.Load() > 0
+}
+
+// waitSettled blocks until all the abandoned calls of the session return.
+func (s *Session) waitSettled() {
+	s.settled.Wait()
+}
+
+// callCtx runs call in a separate goroutine and waits for it to return or
+// for ctx to be done. It reports whether call completed, in which case the
+// results of call can be used. If ctx is done first, ctx.Err() is returned
+// right away and the session stays poisoned until call returns. The
+// goroutine keeps every value captured by call alive until then, and runs
+// cleanup, if not nil, so results of the abandoned call are not leaked.
+// cleanup also runs if call is not started at all.
+//
+// The handles in pins are pinned while call runs, so that the C allocations
+// of its inputs outlive an abandoned call even if the caller releases them.
+// call must use copies of the inputs that are not changed by their release.
+func (s *Session) callCtx(ctx context.Context, op string, call func() int, cleanup func(),
+	pins ...*handle) (bool, error) {
+	notStarted := func(err error) (bool, error) {
+		if cleanup != nil {
+			cleanup()
+		}
+		return false, err
+	}
+	if s == nil || ctx == nil {
+		return notStarted(NewError(op, EINVAL))
+	}
+	if err := ctx.Err(); err != nil {
+		return notStarted(err)
+	}
+	if s.Poisoned() {
+		return notStarted(&Error{Code: EBUSY, Op: op, Msg: "session has an abandoned call in flight"})
+	}
+
+	for _, h := range pins {
+		h.pin()
+	}
+	done := make(chan int, 1)
+	go func() {
+		ret := call()
+		for _, h := range pins {
+			h.unpin()
+		}
+		done <- ret
+	}()
+
+	select {
+	case ret := <-done:
+		return true, NewError(op, ret)
+	case <-ctx.Done():
+		s.abandoned.Add(1)
+		s.settled.Add(1)
+		go func() {
+			<-done
+			if cleanup != nil {
+				cleanup()
+			}
+			s.abandoned.Add(-1)
+			s.settled.Done()
+		}()
+		return false, ctx.Err()
+	}
+}
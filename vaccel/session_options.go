@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type sessionOptions struct {
+	hint   SessionHint
+	local  bool
+	plugin string
+}
+
+// SessionOption configures a session created with NewSession.
+type SessionOption func(*sessionOptions)
+
+// WithHint adds the given plugin classes to the session hint.
+func WithHint(h SessionHint) SessionOption {
+	return func(o *sessionOptions) {
+		o.hint |= h
+	}
+}
+
+// WithLocal asks for operations to run with a plugin on the local host.
+// It can not be combined with WithRemote.
+func WithLocal() SessionOption {
+	return func(o *sessionOptions) {
+		o.local = true
+	}
+}
+
+// WithRemote asks for operations to run with a remote plugin, i.e. through
+// a vAccel agent.
+func WithRemote() SessionOption {
+	return WithHint(HintRemote)
+}
+
+// WithCPU asks for a plugin running operations on the CPU.
+func WithCPU() SessionOption {
+	return WithHint(HintCPU)
+}
+
+// WithGPU asks for a plugin running operations on a GPU.
+func WithGPU() SessionOption {
+	return WithHint(HintGPU)
+}
+
+// WithFPGA asks for a plugin running operations on an FPGA.
+func WithFPGA() SessionOption {
+	return WithHint(HintFPGA)
+}
+
+// WithPlugin requires the plugin with the given name, e.g. "torch" or
+// "libvaccel-torch.so", to be among the plugins vAccel loads, which are the
+// ones listed in VACCEL_PLUGINS. NewSession fails with an ErrInvalid error
+// if it is not. vAccel picks the plugin of each operation by the session
+// hint, so combine it with the options of the plugin's classes.
+func WithPlugin(name string) SessionOption {
+	return func(o *sessionOptions) {
+		o.plugin = name
+	}
+}
+
+func (o *sessionOptions) validate() error {
+	if o.local && o.hint.IsRemote() {
+		return &Error{Code: EINVAL, Op: "NewSession",
+			Msg: "local and remote options are mutually exclusive"}
+	}
+	if err := o.hint.Validate(); err != nil {
+		return err
+	}
+	if o.plugin != "" && !pluginLoaded(o.plugin) {
+		return &Error{Code: EINVAL, Op: "NewSession",
+			Msg: fmt.Sprintf("unknown plugin %q; loaded plugins are %q", o.plugin, loadedPlugins())}
+	}
+	return nil
+}
+
+// loadedPlugins returns the paths of the plugins listed in VACCEL_PLUGINS.
+func loadedPlugins() []string {
+	var paths []string
+	for _, p := range strings.Split(os.Getenv("VACCEL_PLUGINS"), ":") {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// pluginLoaded reports whether name matches one of the loaded plugins,
+// either by path, by file name or by the short name of the plugin
+// (libvaccel-<name>.so).
+func pluginLoaded(name string) bool {
+	for _, p := range loadedPlugins() {
+		base := filepath.Base(p)
+		short := strings.TrimSuffix(strings.TrimPrefix(base, "libvaccel-"), ".so")
+		if name == p || name == base || name == short {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSession creates a session configured by opts.
+func NewSession(opts ...SessionOption) (*Session, error) {
+	var o sessionOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	s := new(Session)
+	if err := s.InitErr(uint32(o.hint)); err != nil {
+		return nil, err
+	}
+	s.plugin = o.plugin
+
+	return s, nil
+}
@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSessionAbandonedCall(t *testing.T) {
+	sess := newTestSession(t)
+
+	in, err := NewTorchTensorFrom([]float32{1, 2, 3})
+	if err != nil {
+		t.Fatalf("NewTorchTensorFrom: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started, unblock := make(chan struct{}), make(chan struct{})
+	go func() {
+		<-started
+		cancel()
+	}()
+	completed, err := sess.callCtx(ctx, "test", func() int {
+		close(started)
+		<-unblock
+		return OK
+	}, nil, in.h)
+	if completed || !errors.Is(err, context.Canceled) {
+		t.Fatalf("callCtx = %v, %v, want false, context.Canceled", completed, err)
+	}
+	if !sess.Poisoned() {
+		t.Fatalf("session is not poisoned")
+	}
+
+	if _, ret := ImageClassification(sess, []byte{0}); ret != EBUSY {
+		t.Errorf("ImageClassification on a poisoned session = %d, want EBUSY", ret)
+	}
+	if err := ExecLibraryArgs(sess, "lib.so", "f", nil, nil); !errors.Is(err, ErrBusy) {
+		t.Errorf("ExecLibraryArgs on a poisoned session = %v, want ErrBusy", err)
+	}
+	if err := sess.ReleaseErr(); !errors.Is(err, ErrBusy) {
+		t.Errorf("Session.Release of a poisoned session = %v, want ErrBusy", err)
+	}
+
+	/* inputs of the abandoned call are released once it returns */
+	h := in.h
+	if err := in.Close(); err != nil {
+		t.Fatalf("TorchTensor.Close of a pinned tensor: %v", err)
+	}
+	if h.state.released {
+		t.Fatalf("pinned tensor released while the call runs")
+	}
+
+	close(unblock)
+	sess.waitSettled()
+	if sess.Poisoned() {
+		t.Errorf("session still poisoned after the call returned")
+	}
+	if !h.state.released {
+		t.Errorf("pinned tensor not released after the call returned")
+	}
+}
+
+func TestSessionResources(t *testing.T) {
+	sess := newTestSession(t)
+	kept := newTestExecutableResource(t, ResourceData)
+	released := newTestExecutableResource(t, ResourceData)
+
+	for _, r := range []*Resource{released, kept} {
+		if err := sess.RegisterErr(r); err != nil {
+			t.Skipf("can not register resource: %v", err)
+		}
+	}
+	id := kept.GetID()
+
+	/* released by its owner while still registered */
+	if err := released.Close(); err != nil {
+		t.Fatalf("Resource.Close: %v", err)
+	}
+	if got := released.GetID(); got != -1 {
+		t.Errorf("GetID of a released resource = %d, want -1", got)
+	}
+
+	infos := sess.Resources()
+	if len(infos) != 1 || infos[0].Resource != kept || infos[0].ID != id || infos[0].Type != ResourceData {
+		t.Errorf("Resources = %+v, want only the resource that was not released", infos)
+	}
+}
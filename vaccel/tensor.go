@@ -0,0 +1,317 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+)
+
+// DType is the data type of the elements of a tensor, independent of the
+// framework the tensor is for.
+type DType int
+
+const (
+	DTypeInvalid DType = iota
+	DTypeBool
+	DTypeInt8
+	DTypeInt16
+	DTypeInt32
+	DTypeInt64
+	DTypeUint8
+	DTypeUint16
+	DTypeUint32
+	DTypeUint64
+	DTypeFloat16
+	DTypeBFloat16
+	DTypeFloat32
+	DTypeFloat64
+	DTypeComplex64
+	DTypeComplex128
+	DTypeString
+)
+
+var dtypeNames = map[DType]string{
+	DTypeInvalid:    "invalid",
+	DTypeBool:       "bool",
+	DTypeInt8:       "int8",
+	DTypeInt16:      "int16",
+	DTypeInt32:      "int32",
+	DTypeInt64:      "int64",
+	DTypeUint8:      "uint8",
+	DTypeUint16:     "uint16",
+	DTypeUint32:     "uint32",
+	DTypeUint64:     "uint64",
+	DTypeFloat16:    "float16",
+	DTypeBFloat16:   "bfloat16",
+	DTypeFloat32:    "float32",
+	DTypeFloat64:    "float64",
+	DTypeComplex64:  "complex64",
+	DTypeComplex128: "complex128",
+	DTypeString:     "string",
+}
+
+func (d DType) String() string {
+	if name, ok := dtypeNames[d]; ok {
+		return name
+	}
+	return fmt.Sprintf("DType(%d)", int(d))
+}
+
+var tfDTypes = map[TFDataType]DType{
+	TfBool:       DTypeBool,
+	TfInt8:       DTypeInt8,
+	TfInt16:      DTypeInt16,
+	TfInt32:      DTypeInt32,
+	TfInt64:      DTypeInt64,
+	TfUint8:      DTypeUint8,
+	TfUint16:     DTypeUint16,
+	TfUint32:     DTypeUint32,
+	TfUint64:     DTypeUint64,
+	TfHalf:       DTypeFloat16,
+	TfBfloat16:   DTypeBFloat16,
+	TfFloat:      DTypeFloat32,
+	TfDouble:     DTypeFloat64,
+	TfComplex64:  DTypeComplex64,
+	TfComplex128: DTypeComplex128,
+	TfString:     DTypeString,
+}
+
+var torchDTypes = map[TorchDataType]DType{
+	TorchByte:  DTypeUint8,
+	TorchChar:  DTypeInt8,
+	TorchShort: DTypeInt16,
+	TorchInt:   DTypeInt32,
+	TorchLong:  DTypeInt64,
+	TorchHalf:  DTypeFloat16,
+	TorchFloat: DTypeFloat32,
+}
+
+var tfliteDTypes = map[TFLiteDataType]DType{
+	TfLiteBool:       DTypeBool,
+	TfLiteInt8:       DTypeInt8,
+	TfLiteInt16:      DTypeInt16,
+	TfLiteInt32:      DTypeInt32,
+	TfLiteInt64:      DTypeInt64,
+	TfLiteUint8:      DTypeUint8,
+	TfLiteUint16:     DTypeUint16,
+	TfLiteUint32:     DTypeUint32,
+	TfLiteUint64:     DTypeUint64,
+	TfLiteFloat16:    DTypeFloat16,
+	TfLiteFloat32:    DTypeFloat32,
+	TfLiteFloat64:    DTypeFloat64,
+	TfLiteComplex64:  DTypeComplex64,
+	TfLiteComplex128: DTypeComplex128,
+	TfLiteString:     DTypeString,
+}
+
+// DType returns the neutral data type of t, or DTypeInvalid if there is
+// none, like for quantized types.
+func (t TFDataType) DType() DType {
+	return tfDTypes[t]
+}
+
+// DType returns the neutral data type of t, or DTypeInvalid if there is
+// none.
+func (t TorchDataType) DType() DType {
+	return torchDTypes[t]
+}
+
+// DType returns the neutral data type of t, or DTypeInvalid if there is
+// none, like for int4.
+func (t TFLiteDataType) DType() DType {
+	return tfliteDTypes[t]
+}
+
+// TF returns the TensorFlow data type of d. It reports false if TensorFlow
+// has none.
+func (d DType) TF() (TFDataType, bool) {
+	return lookupDType(tfDTypes, d)
+}
+
+// Torch returns the Torch data type of d. It reports false if the Torch
+// plugin has none.
+func (d DType) Torch() (TorchDataType, bool) {
+	return lookupDType(torchDTypes, d)
+}
+
+// TFLite returns the TensorFlow Lite data type of d. It reports false if
+// TensorFlow Lite has none.
+func (d DType) TFLite() (TFLiteDataType, bool) {
+	return lookupDType(tfliteDTypes, d)
+}
+
+func lookupDType[T comparable](types map[T]DType, d DType) (T, bool) {
+	for t, dt := range types {
+		if dt == d && d != DTypeInvalid {
+			return t, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// Tensor is the framework-neutral view of a TFTensor, a TorchTensor or a
+// TFLiteTensor, for code that works with tensors of any framework.
+type Tensor interface {
+	DType() DType
+	Shape() []int64
+	NrDims() int
+	Size() int
+	Data() uintptr
+	SetData(data uintptr, size uint, own bool) int
+	TakeData() (uintptr, uint)
+	io.Closer
+
+	// tensorData returns the C memory of the tensor data, which is valid
+	// while the tensor is kept alive.
+	tensorData() []byte
+}
+
+var (
+	_ Tensor = (*TFTensor)(nil)
+	_ Tensor = (*TorchTensor)(nil)
+	_ Tensor = (*TFLiteTensor)(nil)
+)
+
+// DType returns the neutral data type of the tensor.
+func (t *TFTensor) DType() DType {
+	return t.Type().DType()
+}
+
+// Shape returns the dims of the tensor.
+func (t *TFTensor) Shape() []int64 {
+	return t.Dims()
+}
+
+// DType returns the neutral data type of the tensor.
+func (t *TorchTensor) DType() DType {
+	return t.Type().DType()
+}
+
+// Shape returns the dims of the tensor.
+func (t *TorchTensor) Shape() []int64 {
+	return t.Dims()
+}
+
+// DType returns the neutral data type of the tensor.
+func (t *TFLiteTensor) DType() DType {
+	return t.Type().DType()
+}
+
+// Shape returns the dims of the tensor, as int64 like for the other
+// frameworks.
+func (t *TFLiteTensor) Shape() []int64 {
+	dims := t.Dims()
+	if dims == nil {
+		return nil
+	}
+	shape := make([]int64, len(dims))
+	for i, d := range dims {
+		shape[i] = int64(d)
+	}
+	return shape
+}
+
+// ToTFTensor returns a new TFTensor with the dims, data type and a copy of
+// the data of t.
+func ToTFTensor(t Tensor) (*TFTensor, error) {
+	const op = "ToTFTensor"
+
+	shape, dtype, err := convertibleTensor(op, t)
+	if err != nil {
+		return nil, err
+	}
+	tfType, ok := dtype.TF()
+	if !ok {
+		return nil, unsupportedDType(op, dtype, "TensorFlow")
+	}
+
+	out := &TFTensor{}
+	if err := out.AllocateErr(shape, tfType, uint(t.Size())); err != nil {
+		return nil, err
+	}
+	copy(out.tensorData(), t.tensorData())
+	runtime.KeepAlive(t)
+	return out, nil
+}
+
+// ToTorchTensor returns a new TorchTensor with the dims, data type and a
+// copy of the data of t.
+func ToTorchTensor(t Tensor) (*TorchTensor, error) {
+	const op = "ToTorchTensor"
+
+	shape, dtype, err := convertibleTensor(op, t)
+	if err != nil {
+		return nil, err
+	}
+	torchType, ok := dtype.Torch()
+	if !ok {
+		return nil, unsupportedDType(op, dtype, "Torch")
+	}
+
+	out := &TorchTensor{}
+	if err := out.AllocateErr(shape, torchType, uint(t.Size())); err != nil {
+		return nil, err
+	}
+	copy(out.tensorData(), t.tensorData())
+	runtime.KeepAlive(t)
+	return out, nil
+}
+
+// ToTFLiteTensor returns a new TFLiteTensor with the dims, data type and a
+// copy of the data of t. It fails if a dim of t does not fit in an int32.
+func ToTFLiteTensor(t Tensor) (*TFLiteTensor, error) {
+	const op = "ToTFLiteTensor"
+
+	shape, dtype, err := convertibleTensor(op, t)
+	if err != nil {
+		return nil, err
+	}
+	tfliteType, ok := dtype.TFLite()
+	if !ok {
+		return nil, unsupportedDType(op, dtype, "TensorFlow Lite")
+	}
+
+	dims := make([]int32, len(shape))
+	for i, d := range shape {
+		if d < math.MinInt32 || d > math.MaxInt32 {
+			return nil, &Error{Code: EINVAL, Op: op,
+				Msg: fmt.Sprintf("dim %d of %d does not fit in an int32", i, d)}
+		}
+		dims[i] = int32(d)
+	}
+
+	out := &TFLiteTensor{}
+	if err := out.AllocateErr(dims, tfliteType, uint(t.Size())); err != nil {
+		return nil, err
+	}
+	copy(out.tensorData(), t.tensorData())
+	runtime.KeepAlive(t)
+	return out, nil
+}
+
+// convertibleTensor returns the shape and the data type of the initialized
+// tensor t.
+func convertibleTensor(op string, t Tensor) ([]int64, DType, error) {
+	if t == nil {
+		return nil, DTypeInvalid, NewError(op, EINVAL)
+	}
+	shape := t.Shape()
+	if shape == nil {
+		return nil, DTypeInvalid, &Error{Code: EINVAL, Op: op, Msg: "tensor is not initialized"}
+	}
+	dtype := t.DType()
+	if dtype == DTypeString {
+		/* each framework encodes strings its own way */
+		return nil, DTypeInvalid, unsupportedDType(op, dtype, "conversions")
+	}
+	return shape, dtype, nil
+}
+
+func unsupportedDType(op string, dtype DType, framework string) error {
+	return &Error{Code: ENOTSUP, Op: op,
+		Msg: fmt.Sprintf("data type %v is not supported by %s", dtype, framework)}
+}
@@ -0,0 +1,254 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	// tensorSummaryThreshold is the number of elements above which tensors
+	// are summarized.
+	tensorSummaryThreshold = 1000
+	// tensorEdgeItems is the number of elements at each end of a dim that a
+	// summarized tensor shows.
+	tensorEdgeItems = 3
+	// tensorPrecision is the default number of decimals of floating-point
+	// elements.
+	tensorPrecision = 4
+)
+
+// Format implements fmt.Formatter. It formats the elements of the tensor
+// like NumPy does, as nested, comma-separated lists, one per dim:
+//
+//	%v, %s      elements, with floating-point ones like %f
+//	%f, %e, %g  floating-point elements like the verb
+//	%d          same as %v
+//	%.2v        2 decimals for floating-point elements instead of 4
+//	%+v         a shape and data type line before the elements
+//	%#v         all elements, even of large tensors
+//
+// Tensors of more than 1000 elements are summarized, with the first and last
+// 3 elements of each dim around a "...".
+func (t *TFTensor) Format(f fmt.State, verb rune) {
+	formatTensor(f, verb, t)
+}
+
+// String returns the elements of the tensor as formatted with %v.
+func (t *TFTensor) String() string {
+	return fmt.Sprintf("%v", t)
+}
+
+// Format implements fmt.Formatter, like TFTensor.Format.
+func (t *TorchTensor) Format(f fmt.State, verb rune) {
+	formatTensor(f, verb, t)
+}
+
+// String returns the elements of the tensor as formatted with %v.
+func (t *TorchTensor) String() string {
+	return fmt.Sprintf("%v", t)
+}
+
+// Format implements fmt.Formatter, like TFTensor.Format.
+func (t *TFLiteTensor) Format(f fmt.State, verb rune) {
+	formatTensor(f, verb, t)
+}
+
+// String returns the elements of the tensor as formatted with %v.
+func (t *TFLiteTensor) String() string {
+	return fmt.Sprintf("%v", t)
+}
+
+// tensorPrinter writes the elements of a tensor.
+type tensorPrinter struct {
+	sb        strings.Builder
+	shape     []int64
+	data      []byte
+	elemSize  int
+	elem      func(b []byte) string
+	summarize bool
+}
+
+func formatTensor(f fmt.State, verb rune, t Tensor) {
+	switch verb {
+	case 'v', 's', 'd', 'f', 'F', 'e', 'E', 'g', 'G':
+	default:
+		fmt.Fprintf(f, "%%!%c(%T)", verb, t)
+		return
+	}
+
+	defer runtime.KeepAlive(t)
+
+	if err := validateFormatted(t); err != nil {
+		var vErr *Error
+		if errors.As(err, &vErr) {
+			err = errors.New(vErr.Msg)
+		}
+		fmt.Fprintf(f, "<invalid tensor: %v>", err)
+		return
+	}
+
+	shape, dtype := t.Shape(), t.DType()
+	if f.Flag('+') {
+		fmt.Fprintf(f, "shape=%v dtype=%v\n", shape, dtype)
+	}
+
+	prec, ok := f.Precision()
+	if !ok {
+		prec = tensorPrecision
+		if verb == 'g' || verb == 'G' {
+			prec = -1
+		}
+	}
+	/* strconv has no 'F' format, which is a synonym for 'f' in fmt */
+	fmtByte := byte('f')
+	switch verb {
+	case 'e', 'E', 'g', 'G':
+		fmtByte = byte(verb)
+	}
+
+	elem := elemFormatter(dtype, fmtByte, prec)
+	if elem == nil {
+		if dtype == DTypeInvalid {
+			fmt.Fprintf(f, "<%d bytes of data of an unknown type>", len(t.tensorData()))
+		} else {
+			fmt.Fprintf(f, "<%d bytes of %v data>", len(t.tensorData()), dtype)
+		}
+		return
+	}
+
+	elems, _ := tensorElems("", shape)
+	p := &tensorPrinter{
+		shape:     shape,
+		data:      t.tensorData(),
+		elemSize:  dtype.elemSize(),
+		elem:      elem,
+		summarize: !f.Flag('#') && elems > tensorSummaryThreshold,
+	}
+	p.print(0, 0)
+	f.Write([]byte(p.sb.String()))
+}
+
+// validateFormatted checks t with its Validate method, so that only tensors
+// that are consistent are formatted.
+func validateFormatted(t Tensor) error {
+	if v, ok := t.(interface{ Validate() error }); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// print writes the elements of dim level that start at element offset.
+func (p *tensorPrinter) print(level int, offset int) {
+	n := int(p.shape[level])
+	stride := 1
+	for _, d := range p.shape[level+1:] {
+		stride *= int(d)
+	}
+
+	sep := ", "
+	if level < len(p.shape)-1 {
+		sep = "," + strings.Repeat("\n", len(p.shape)-level-1) + strings.Repeat(" ", level+1)
+	}
+
+	p.sb.WriteByte('[')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			p.sb.WriteString(sep)
+		}
+		if p.summarize && n > 2*tensorEdgeItems && i == tensorEdgeItems {
+			p.sb.WriteString("...")
+			p.sb.WriteString(sep)
+			i = n - tensorEdgeItems
+		}
+
+		start := offset + i*stride
+		if level == len(p.shape)-1 {
+			p.sb.WriteString(p.elem(p.data[start*p.elemSize : (start+1)*p.elemSize]))
+		} else {
+			p.print(level+1, start)
+		}
+	}
+	p.sb.WriteByte(']')
+}
+
+// elemFormatter returns a function that formats an element of dtype, with
+// floating-point elements formatted like strconv.FormatFloat with fmtByte
+// and prec. It returns nil for non-numeric types.
+func elemFormatter(dtype DType, fmtByte byte, prec int) func(b []byte) string {
+	ne := binary.NativeEndian
+	float := func(v float64, bitSize int) string {
+		return strconv.FormatFloat(v, fmtByte, prec, bitSize)
+	}
+
+	switch dtype {
+	case DTypeBool:
+		return func(b []byte) string { return strconv.FormatBool(b[0] != 0) }
+	case DTypeInt8:
+		return func(b []byte) string { return strconv.FormatInt(int64(int8(b[0])), 10) }
+	case DTypeInt16:
+		return func(b []byte) string { return strconv.FormatInt(int64(int16(ne.Uint16(b))), 10) }
+	case DTypeInt32:
+		return func(b []byte) string { return strconv.FormatInt(int64(int32(ne.Uint32(b))), 10) }
+	case DTypeInt64:
+		return func(b []byte) string { return strconv.FormatInt(int64(ne.Uint64(b)), 10) }
+	case DTypeUint8:
+		return func(b []byte) string { return strconv.FormatUint(uint64(b[0]), 10) }
+	case DTypeUint16:
+		return func(b []byte) string { return strconv.FormatUint(uint64(ne.Uint16(b)), 10) }
+	case DTypeUint32:
+		return func(b []byte) string { return strconv.FormatUint(uint64(ne.Uint32(b)), 10) }
+	case DTypeUint64:
+		return func(b []byte) string { return strconv.FormatUint(ne.Uint64(b), 10) }
+	case DTypeFloat16:
+		return func(b []byte) string { return float(float64(float16ToFloat32(ne.Uint16(b))), 32) }
+	case DTypeBFloat16:
+		return func(b []byte) string {
+			return float(float64(math.Float32frombits(uint32(ne.Uint16(b))<<16)), 32)
+		}
+	case DTypeFloat32:
+		return func(b []byte) string { return float(float64(math.Float32frombits(ne.Uint32(b))), 32) }
+	case DTypeFloat64:
+		return func(b []byte) string { return float(math.Float64frombits(ne.Uint64(b)), 64) }
+	case DTypeComplex64:
+		return func(b []byte) string {
+			c := complex(math.Float32frombits(ne.Uint32(b)), math.Float32frombits(ne.Uint32(b[4:])))
+			return strconv.FormatComplex(complex128(c), fmtByte, prec, 64)
+		}
+	case DTypeComplex128:
+		return func(b []byte) string {
+			c := complex(math.Float64frombits(ne.Uint64(b)), math.Float64frombits(ne.Uint64(b[8:])))
+			return strconv.FormatComplex(c, fmtByte, prec, 128)
+		}
+	}
+	return nil
+}
+
+// float16ToFloat32 converts the IEEE 754 half-precision number h.
+func float16ToFloat32(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1f
+	frac := uint32(h) & 0x3ff
+
+	switch {
+	case exp == 0x1f:
+		/* infinity or NaN */
+		return math.Float32frombits(sign | 0xff<<23 | frac<<13)
+	case exp == 0 && frac == 0:
+		return math.Float32frombits(sign)
+	case exp == 0:
+		/* subnormal: frac * 2^-24 */
+		v := float32(frac) / (1 << 24)
+		if sign != 0 {
+			v = -v
+		}
+		return v
+	}
+	return math.Float32frombits(sign | (exp+127-15)<<23 | frac<<13)
+}
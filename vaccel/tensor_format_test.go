@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestTensorFormat(t *testing.T) {
+	f32, err := NewTorchTensorFrom([]float32{1, 2.5, -3, 4, 5, 6}, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f32.Close()
+
+	i64, err := NewTFTensorFrom([]int64{-1, math.MaxInt64, 0, 7, 8, 9, 10, 11}, 2, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer i64.Close()
+
+	u8, err := NewTFLiteTensorFrom([]uint8{0, 255})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer u8.Close()
+
+	c64, err := NewTFTensorFrom([]complex64{complex(1, -2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c64.Close()
+
+	var half TFLiteTensor
+	if err := half.AllocateErr([]int32{3}, TfLiteFloat16, 6); err != nil {
+		t.Fatal(err)
+	}
+	defer half.Close()
+	copy(half.tensorData(), []byte{0x00, 0x3c, 0x00, 0xc0, 0x00, 0x7c}) /* 1, -2, +Inf */
+
+	tests := []struct {
+		format string
+		tensor any
+		want   string
+	}{
+		{"%v", f32, "[[1.0000, 2.5000, -3.0000],\n [4.0000, 5.0000, 6.0000]]"},
+		{"%.1v", f32, "[[1.0, 2.5, -3.0],\n [4.0, 5.0, 6.0]]"},
+		{"%g", f32, "[[1, 2.5, -3],\n [4, 5, 6]]"},
+		{"%.1F", f32, "[[1.0, 2.5, -3.0],\n [4.0, 5.0, 6.0]]"},
+		{"%.1F", c64, "[(1.0-2.0i)]"},
+		{"%.2e", f32, "[[1.00e+00, 2.50e+00, -3.00e+00],\n [4.00e+00, 5.00e+00, 6.00e+00]]"},
+		{"%+v", f32, "shape=[2 3] dtype=float32\n[[1.0000, 2.5000, -3.0000],\n [4.0000, 5.0000, 6.0000]]"},
+		{"%v", i64, "[[[-1, 9223372036854775807],\n  [0, 7]],\n\n [[8, 9],\n  [10, 11]]]"},
+		{"%s", u8, "[0, 255]"},
+		{"%.1v", c64, "[(1.0-2.0i)]"},
+		{"%v", &half, "[1.0000, -2.0000, +Inf]"},
+		{"%x", f32, "%!x(*vaccel.TorchTensor)"},
+		{"%v", &TFTensor{}, "<invalid tensor: tensor is not initialized>"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprintf(tt.format, tt.tensor); got != tt.want {
+			t.Errorf("Sprintf(%q): got\n%s\nwant\n%s", tt.format, got, tt.want)
+		}
+	}
+
+	if got, want := f32.String(), fmt.Sprint(f32); got != want {
+		t.Errorf("String: got %q, want %q", got, want)
+	}
+}
+
+func TestTensorFormatSummary(t *testing.T) {
+	data := make([]int32, 2000)
+	for i := range data {
+		data[i] = int32(i)
+	}
+	tensor, err := NewTorchTensorFrom(data, 2, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tensor.Close()
+
+	want := "[[0, 1, 2, ..., 997, 998, 999],\n [1000, 1001, 1002, ..., 1997, 1998, 1999]]"
+	if got := tensor.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if got := fmt.Sprintf("%#v", tensor); strings.Contains(got, "...") || strings.Count(got, ",") != 1999 {
+		t.Errorf("%%#v is summarized or incomplete: %d commas", strings.Count(got, ","))
+	}
+}
+
+func TestFloat16ToFloat32(t *testing.T) {
+	tests := []struct {
+		h    uint16
+		want float32
+	}{
+		{0x3c00, 1},
+		{0xc000, -2},
+		{0x7bff, 65504},
+		{0x0001, 1.0 / (1 << 24)},
+		{0x8000, float32(math.Copysign(0, -1))},
+	}
+	for _, tt := range tests {
+		if got := float16ToFloat32(tt.h); got != tt.want || math.Signbit(float64(got)) != math.Signbit(float64(tt.want)) {
+			t.Errorf("float16ToFloat32(%#x) = %v, want %v", tt.h, got, tt.want)
+		}
+	}
+	if !math.IsNaN(float64(float16ToFloat32(0x7e00))) {
+		t.Error("NaN is not converted to NaN")
+	}
+}
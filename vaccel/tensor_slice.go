@@ -0,0 +1,196 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"reflect"
+	"runtime"
+	"unsafe"
+)
+
+// Numeric is the element types of the tensors NewTFTensorFrom,
+// NewTorchTensorFrom and NewTFLiteTensorFrom create and AsSlice reads.
+type Numeric interface {
+	~int8 | ~int16 | ~int32 | ~int64 |
+		~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64 | ~complex64 | ~complex128
+}
+
+var kindDTypes = map[reflect.Kind]DType{
+	reflect.Int8:       DTypeInt8,
+	reflect.Int16:      DTypeInt16,
+	reflect.Int32:      DTypeInt32,
+	reflect.Int64:      DTypeInt64,
+	reflect.Uint8:      DTypeUint8,
+	reflect.Uint16:     DTypeUint16,
+	reflect.Uint32:     DTypeUint32,
+	reflect.Uint64:     DTypeUint64,
+	reflect.Float32:    DTypeFloat32,
+	reflect.Float64:    DTypeFloat64,
+	reflect.Complex64:  DTypeComplex64,
+	reflect.Complex128: DTypeComplex128,
+}
+
+// DTypeOf returns the data type of tensors with elements of type T.
+func DTypeOf[T Numeric]() DType {
+	return kindDTypes[reflect.TypeFor[T]().Kind()]
+}
+
+// NewTFTensorFrom returns a new TFTensor of the data type of T with a copy
+// of data. The copy is in C memory owned by the tensor, so data can be
+// reused right away. The tensor is one-dimensional if no dims are given;
+// otherwise len(data) must be the product of dims.
+func NewTFTensorFrom[T Numeric](data []T, dims ...int64) (*TFTensor, error) {
+	const op = "NewTFTensorFrom"
+
+	dtype := DTypeOf[T]()
+	tfType, ok := dtype.TF()
+	if !ok {
+		return nil, unsupportedDType(op, dtype, "TensorFlow")
+	}
+	if len(dims) == 0 {
+		dims = []int64{int64(len(data))}
+	}
+	if err := checkSliceDims(op, len(data), dims); err != nil {
+		return nil, err
+	}
+
+	t := &TFTensor{}
+	if err := t.AllocateErr(dims, tfType, uint(sliceSize(data))); err != nil {
+		return nil, err
+	}
+	copy(t.tensorData(), sliceBytes(data))
+	return t, nil
+}
+
+// NewTorchTensorFrom is like NewTFTensorFrom but returns a TorchTensor.
+func NewTorchTensorFrom[T Numeric](data []T, dims ...int64) (*TorchTensor, error) {
+	const op = "NewTorchTensorFrom"
+
+	dtype := DTypeOf[T]()
+	torchType, ok := dtype.Torch()
+	if !ok {
+		return nil, unsupportedDType(op, dtype, "Torch")
+	}
+	if len(dims) == 0 {
+		dims = []int64{int64(len(data))}
+	}
+	if err := checkSliceDims(op, len(data), dims); err != nil {
+		return nil, err
+	}
+
+	t := &TorchTensor{}
+	if err := t.AllocateErr(dims, torchType, uint(sliceSize(data))); err != nil {
+		return nil, err
+	}
+	copy(t.tensorData(), sliceBytes(data))
+	return t, nil
+}
+
+// NewTFLiteTensorFrom is like NewTFTensorFrom but returns a TFLiteTensor.
+func NewTFLiteTensorFrom[T Numeric](data []T, dims ...int32) (*TFLiteTensor, error) {
+	const op = "NewTFLiteTensorFrom"
+
+	dtype := DTypeOf[T]()
+	tfliteType, ok := dtype.TFLite()
+	if !ok {
+		return nil, unsupportedDType(op, dtype, "TensorFlow Lite")
+	}
+	if len(dims) == 0 {
+		if len(data) > math.MaxInt32 {
+			return nil, &Error{Code: EINVAL, Op: op,
+				Msg: fmt.Sprintf("%d elements do not fit in an int32 dim", len(data))}
+		}
+		dims = []int32{int32(len(data))}
+	}
+	shape := make([]int64, len(dims))
+	for i, d := range dims {
+		shape[i] = int64(d)
+	}
+	if err := checkSliceDims(op, len(data), shape); err != nil {
+		return nil, err
+	}
+
+	t := &TFLiteTensor{}
+	if err := t.AllocateErr(dims, tfliteType, uint(sliceSize(data))); err != nil {
+		return nil, err
+	}
+	copy(t.tensorData(), sliceBytes(data))
+	return t, nil
+}
+
+// AsSlice returns a copy of the data of t as a []T. T must match the data
+// type of t.
+func AsSlice[T Numeric](t Tensor) ([]T, error) {
+	const op = "AsSlice"
+
+	if t == nil {
+		return nil, NewError(op, EINVAL)
+	}
+	if want := DTypeOf[T](); t.DType() != want {
+		return nil, &Error{Code: EINVAL, Op: op,
+			Msg: fmt.Sprintf("tensor has data type %v, not %v", t.DType(), want)}
+	}
+
+	data := t.tensorData()
+	var zero T
+	elemSize := int(unsafe.Sizeof(zero))
+	if len(data)%elemSize != 0 {
+		return nil, &Error{Code: EINVAL, Op: op,
+			Msg: fmt.Sprintf("%d bytes of data are not a multiple of the %d-byte elements", len(data), elemSize)}
+	}
+
+	out := make([]T, len(data)/elemSize)
+	copy(sliceBytes(out), data)
+	runtime.KeepAlive(t)
+	return out, nil
+}
+
+// checkSliceDims checks that dims hold n elements.
+func checkSliceDims(op string, n int, dims []int64) error {
+	elems, err := tensorElems(op, dims)
+	if err != nil {
+		return err
+	}
+	if elems != n {
+		return &Error{Code: EINVAL, Op: op,
+			Msg: fmt.Sprintf("dims %v hold %d elements, but data has %d", dims, elems, n)}
+	}
+	return nil
+}
+
+// tensorElems returns the number of elements of a tensor with dims. It
+// fails for negative dims and for a number that does not fit in an int.
+func tensorElems(op string, dims []int64) (int, error) {
+	elems := uint64(1)
+	for i, d := range dims {
+		if d < 0 {
+			return 0, &Error{Code: EINVAL, Op: op,
+				Msg: fmt.Sprintf("dim %d is negative: %d", i, d)}
+		}
+		hi, lo := bits.Mul64(elems, uint64(d))
+		if hi != 0 || lo > math.MaxInt {
+			return 0, &Error{Code: EINVAL, Op: op,
+				Msg: fmt.Sprintf("dims %v overflow", dims)}
+		}
+		elems = lo
+	}
+	return int(elems), nil
+}
+
+// sliceSize returns the size of the elements of s in bytes.
+func sliceSize[T Numeric](s []T) int {
+	var zero T
+	return len(s) * int(unsafe.Sizeof(zero))
+}
+
+// sliceBytes returns the memory of the elements of s.
+func sliceBytes[T Numeric](s []T) []byte {
+	if len(s) == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(s))), sliceSize(s))
+}
@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestTensorFromSlice(t *testing.T) {
+	data := []float32{1, 2, 3, 4, 5, 6}
+
+	tf, err := NewTFTensorFrom(data, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tf.Close()
+
+	torch, err := NewTorchTensorFrom(data, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer torch.Close()
+
+	tflite, err := NewTFLiteTensorFrom(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tflite.Close()
+
+	data[0] = 0
+	tests := []struct {
+		tensor Tensor
+		shape  []int64
+	}{
+		{tf, []int64{2, 3}},
+		{torch, []int64{3, 2}},
+		{tflite, []int64{6}},
+	}
+	for _, tt := range tests {
+		if shape := tt.tensor.Shape(); !slices.Equal(shape, tt.shape) {
+			t.Errorf("%T: got shape %v, want %v", tt.tensor, shape, tt.shape)
+		}
+		got, err := AsSlice[float32](tt.tensor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []float32{1, 2, 3, 4, 5, 6}; !slices.Equal(got, want) {
+			t.Errorf("%T: got %v, want %v", tt.tensor, got, want)
+		}
+	}
+
+	longs, err := NewTorchTensorFrom([]int64{-1, math.MaxInt64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer longs.Close()
+	if longs.Type() != TorchLong {
+		t.Errorf("got type %v, want %v", longs.Type(), TorchLong)
+	}
+	if got, err := AsSlice[int64](longs); err != nil || !slices.Equal(got, []int64{-1, math.MaxInt64}) {
+		t.Errorf("got %v, %v", got, err)
+	}
+}
+
+func TestTensorFromSliceInvalid(t *testing.T) {
+	if _, err := NewTFTensorFrom([]int32{1, 2, 3}, 2, 2); !errors.Is(err, ErrInvalid) {
+		t.Errorf("size mismatch: got %v, want %v", err, ErrInvalid)
+	}
+	if _, err := NewTorchTensorFrom([]float32{1}, -1, -1); !errors.Is(err, ErrInvalid) {
+		t.Errorf("negative dims: got %v, want %v", err, ErrInvalid)
+	}
+	if _, err := NewTFTensorFrom([]uint8{}, math.MaxInt64, 4, 0); !errors.Is(err, ErrInvalid) {
+		t.Errorf("overflow: got %v, want %v", err, ErrInvalid)
+	}
+	if _, err := NewTorchTensorFrom([]float64{1}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("float64 Torch tensor: got %v, want %v", err, ErrNotSupported)
+	}
+
+	tensor, err := NewTFLiteTensorFrom([]int32{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tensor.Close()
+	if _, err := AsSlice[float32](tensor); !errors.Is(err, ErrInvalid) {
+		t.Errorf("dtype mismatch: got %v, want %v", err, ErrInvalid)
+	}
+	if _, err := AsSlice[int32](nil); !errors.Is(err, ErrInvalid) {
+		t.Errorf("nil tensor: got %v, want %v", err, ErrInvalid)
+	}
+}
+
+func TestTensorElems(t *testing.T) {
+	tests := []struct {
+		dims []int64
+		want int
+		ok   bool
+	}{
+		{[]int64{2, 3, 4}, 24, true},
+		{[]int64{5, 0}, 0, true},
+		{[]int64{}, 1, true},
+		{[]int64{3, -1}, 0, false},
+		{[]int64{math.MaxInt64, 2}, 0, false},
+		{[]int64{1 << 32, 1 << 32}, 0, false},
+	}
+	for _, tt := range tests {
+		got, err := tensorElems("test", tt.dims)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("tensorElems(%v) = %d, %v", tt.dims, got, err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+	"unsafe"
+)
+
+func TestDTypeRoundTrip(t *testing.T) {
+	for tfType, dtype := range tfDTypes {
+		if got, ok := dtype.TF(); !ok || got != tfType {
+			t.Errorf("%v.TF() = %v, %v, want %v", dtype, got, ok, tfType)
+		}
+	}
+	for torchType, dtype := range torchDTypes {
+		if got, ok := dtype.Torch(); !ok || got != torchType {
+			t.Errorf("%v.Torch() = %v, %v, want %v", dtype, got, ok, torchType)
+		}
+	}
+	for tfliteType, dtype := range tfliteDTypes {
+		if got, ok := dtype.TFLite(); !ok || got != tfliteType {
+			t.Errorf("%v.TFLite() = %v, %v, want %v", dtype, got, ok, tfliteType)
+		}
+	}
+
+	if dtype := TfQint8.DType(); dtype != DTypeInvalid {
+		t.Errorf("TfQint8.DType() = %v, want %v", dtype, DTypeInvalid)
+	}
+	if _, ok := DTypeFloat64.Torch(); ok {
+		t.Error("float64 has a Torch data type")
+	}
+	if _, ok := DTypeInvalid.TF(); ok {
+		t.Error("invalid has a TF data type")
+	}
+}
+
+func TestTensorConversions(t *testing.T) {
+	data := []float32{1, 2, 3, 4, 5, 6}
+	torch := newTorchFloatTensor(t, data, 2, 3)
+
+	tf, err := ToTFTensor(torch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tf.Close()
+
+	tflite, err := ToTFLiteTensor(tf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tflite.Close()
+
+	back, err := ToTorchTensor(tflite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer back.Close()
+
+	for _, tensor := range []Tensor{tf, tflite, back} {
+		if tensor.DType() != DTypeFloat32 {
+			t.Errorf("%T: got data type %v, want %v", tensor, tensor.DType(), DTypeFloat32)
+		}
+		if shape := tensor.Shape(); !slices.Equal(shape, []int64{2, 3}) {
+			t.Errorf("%T: got shape %v, want [2 3]", tensor, shape)
+		}
+		got := unsafe.Slice((*float32)(unsafe.Pointer(&tensor.tensorData()[0])), len(data))
+		if !slices.Equal(got, data) {
+			t.Errorf("%T: got data %v, want %v", tensor, got, data)
+		}
+	}
+	if tf.Type() != TfFloat || tflite.Type() != TfLiteFloat32 || back.Type() != TorchFloat {
+		t.Errorf("got types %v, %v, %v", tf.Type(), tflite.Type(), back.Type())
+	}
+}
+
+func TestTensorConversionsInvalid(t *testing.T) {
+	var double TFTensor
+	if err := double.AllocateErr([]int64{2}, TfDouble, 16); err != nil {
+		t.Fatal(err)
+	}
+	defer double.Close()
+	if _, err := ToTorchTensor(&double); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("float64 to Torch: got %v, want %v", err, ErrNotSupported)
+	}
+
+	var str TFTensor
+	if err := str.InitErr([]int64{1}, TfString); err != nil {
+		t.Fatal(err)
+	}
+	defer str.Close()
+	if _, err := ToTFLiteTensor(&str); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("string: got %v, want %v", err, ErrNotSupported)
+	}
+
+	var large TorchTensor
+	if err := large.InitErr([]int64{math.MaxInt32 + 1}, TorchByte); err != nil {
+		t.Fatal(err)
+	}
+	defer large.Close()
+	if _, err := ToTFLiteTensor(&large); !errors.Is(err, ErrInvalid) {
+		t.Errorf("large dim: got %v, want %v", err, ErrInvalid)
+	}
+
+	if _, err := ToTFTensor(&TorchTensor{}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("uninitialized: got %v, want %v", err, ErrInvalid)
+	}
+}
@@ -0,0 +1,196 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+/* Element sizes in bytes; -1 is for types without a fixed size */
+var tfElemSizes = map[TFDataType]int{
+	TfFloat:      4,
+	TfDouble:     8,
+	TfInt32:      4,
+	TfUint8:      1,
+	TfInt16:      2,
+	TfInt8:       1,
+	TfString:     -1,
+	TfComplex64:  8,
+	TfInt64:      8,
+	TfBool:       1,
+	TfQint8:      1,
+	TfQuint8:     1,
+	TfQint32:     4,
+	TfBfloat16:   2,
+	TfQint16:     2,
+	TfQuint16:    2,
+	TfUint16:     2,
+	TfComplex128: 16,
+	TfHalf:       2,
+	TfResource:   -1,
+	TfVariant:    -1,
+	TfUint32:     4,
+	TfUint64:     8,
+}
+
+var torchElemSizes = map[TorchDataType]int{
+	TorchByte:  1,
+	TorchChar:  1,
+	TorchShort: 2,
+	TorchInt:   4,
+	TorchLong:  8,
+	TorchHalf:  2,
+	TorchFloat: 4,
+}
+
+var tfliteElemSizes = map[TFLiteDataType]int{
+	TfLiteFloat32:    4,
+	TfLiteInt32:      4,
+	TfLiteUint8:      1,
+	TfLiteInt64:      8,
+	TfLiteString:     -1,
+	TfLiteBool:       1,
+	TfLiteInt16:      2,
+	TfLiteComplex64:  8,
+	TfLiteInt8:       1,
+	TfLiteFloat16:    2,
+	TfLiteFloat64:    8,
+	TfLiteComplex128: 16,
+	TfLiteUint64:     8,
+	TfLiteResource:   -1,
+	TfLiteVariant:    -1,
+	TfLiteUint32:     4,
+	TfLiteUint16:     2,
+	TfLiteInt4:       -1,
+}
+
+// ElemSize returns the size in bytes of an element of type t. It returns -1
+// for types without a fixed size, like TfString, and 0 for unknown types.
+func (t TFDataType) ElemSize() int {
+	return tfElemSizes[t]
+}
+
+// ElemSize returns the size in bytes of an element of type t, or 0 for
+// unknown types.
+func (t TorchDataType) ElemSize() int {
+	return torchElemSizes[t]
+}
+
+// ElemSize returns the size in bytes of an element of type t. It returns -1
+// for types without a fixed size, like TfLiteString, or with elements
+// smaller than a byte, like TfLiteInt4, and 0 for unknown types.
+func (t TFLiteDataType) ElemSize() int {
+	return tfliteElemSizes[t]
+}
+
+// elemSize returns the size in bytes of an element of d, or 0 for
+// DTypeInvalid and unknown types. TensorFlow has a type for every DType, so
+// the size comes from its table.
+func (d DType) elemSize() int {
+	t, ok := d.TF()
+	if !ok {
+		return 0
+	}
+	return t.ElemSize()
+}
+
+// Validate checks that the tensor is initialized with non-negative dims and
+// a known data type, and that it has data of the size its dims and data type
+// call for. The size of the data of types without a fixed size is not
+// checked.
+func (t *TFTensor) Validate() error {
+	if t == nil || t.cTFTensor == nil {
+		return &Error{Code: EINVAL, Op: "TFTensor.Validate", Msg: "tensor is not initialized"}
+	}
+	return validateTensor("TFTensor.Validate", t.Shape(), t.Type(), t.Type().ElemSize(),
+		t.DataPtr() != 0, t.Size())
+}
+
+// Validate is like TFTensor.Validate.
+func (t *TorchTensor) Validate() error {
+	if t == nil || t.cTorchTensor == nil {
+		return &Error{Code: EINVAL, Op: "TorchTensor.Validate", Msg: "tensor is not initialized"}
+	}
+	return validateTensor("TorchTensor.Validate", t.Shape(), t.Type(), t.Type().ElemSize(),
+		t.DataPtr() != 0, t.Size())
+}
+
+// Validate is like TFTensor.Validate. TfLiteInt4 data is checked to hold
+// two elements per byte.
+func (t *TFLiteTensor) Validate() error {
+	if t == nil || t.cTFLiteTensor == nil {
+		return &Error{Code: EINVAL, Op: "TFLiteTensor.Validate", Msg: "tensor is not initialized"}
+	}
+
+	shape, dtype := t.Shape(), t.Type()
+	if dtype != TfLiteInt4 {
+		return validateTensor("TFLiteTensor.Validate", shape, dtype, dtype.ElemSize(),
+			t.DataPtr() != 0, t.Size())
+	}
+
+	if err := validateTensor("TFLiteTensor.Validate", shape, dtype, -1,
+		t.DataPtr() != 0, t.Size()); err != nil {
+		return err
+	}
+	elems, _ := tensorElems("TFLiteTensor.Validate", shape)
+	if want := elems/2 + elems%2; t.Size() != want {
+		return &Error{Code: EINVAL, Op: "TFLiteTensor.Validate",
+			Msg: fmt.Sprintf("tensor has %d bytes of data, want %d for %d int4 elements", t.Size(), want, elems)}
+	}
+	return nil
+}
+
+// validateTensor checks a tensor with shape, elements of dtype that are
+// elemSize bytes each and size bytes of data, if hasData, as documented in
+// TFTensor.Validate.
+func validateTensor(op string, shape []int64, dtype any, elemSize int, hasData bool, size int) error {
+	if len(shape) == 0 {
+		return &Error{Code: EINVAL, Op: op, Msg: "tensor has no dims"}
+	}
+	elems, err := tensorElems(op, shape)
+	if err != nil {
+		return err
+	}
+	if elemSize == 0 {
+		return &Error{Code: EINVAL, Op: op, Msg: fmt.Sprintf("unknown data type %v", dtype)}
+	}
+	if !hasData && (elems > 0 || size > 0) {
+		return &Error{Code: EINVAL, Op: op, Msg: "tensor has no data"}
+	}
+	if elemSize < 0 {
+		return nil
+	}
+
+	hi, want := bits.Mul64(uint64(elems), uint64(elemSize))
+	if hi != 0 || want > math.MaxInt {
+		return &Error{Code: EINVAL, Op: op,
+			Msg: fmt.Sprintf("dims %v of %d-byte elements overflow", shape, elemSize)}
+	}
+	if uint64(size) != want {
+		return &Error{Code: EINVAL, Op: op,
+			Msg: fmt.Sprintf("tensor has %d bytes of data, want %d for dims %v of %d-byte elements",
+				size, want, shape, elemSize)}
+	}
+	return nil
+}
+
+// validateTensors checks each of tensors with Validate.
+func validateTensors[T any, P interface {
+	*T
+	Validate() error
+}](op string, tensors []T) error {
+	for i := range tensors {
+		if err := P(&tensors[i]).Validate(); err != nil {
+			msg := err.Error()
+			var vErr *Error
+			if errors.As(err, &vErr) {
+				msg = vErr.Msg
+			}
+			return &Error{Code: EINVAL, Op: op, Msg: fmt.Sprintf("input tensor %d: %s", i, msg)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestElemSizes(t *testing.T) {
+	for tfType, dtype := range tfDTypes {
+		if dtype.elemSize() != tfType.ElemSize() {
+			t.Errorf("%v: element size %d, TF element size %d", dtype, dtype.elemSize(), tfType.ElemSize())
+		}
+		if dtype == DTypeString {
+			continue
+		}
+		if tfliteType, ok := dtype.TFLite(); ok && tfliteType.ElemSize() != tfType.ElemSize() {
+			t.Errorf("%v: TFLite element size %d, TF element size %d", dtype, tfliteType.ElemSize(), tfType.ElemSize())
+		}
+		if torchType, ok := dtype.Torch(); ok && torchType.ElemSize() != tfType.ElemSize() {
+			t.Errorf("%v: Torch element size %d, TF element size %d", dtype, torchType.ElemSize(), tfType.ElemSize())
+		}
+	}
+	/* dtypeNames has DTypeInvalid too */
+	if len(tfDTypes) != len(dtypeNames)-1 || DTypeInvalid.elemSize() != 0 {
+		t.Errorf("TF has %d of the %d data types, DTypeInvalid element size %d",
+			len(tfDTypes), len(dtypeNames)-1, DTypeInvalid.elemSize())
+	}
+	if TfString.ElemSize() != -1 || TFDataType(100).ElemSize() != 0 {
+		t.Errorf("got %d and %d", TfString.ElemSize(), TFDataType(100).ElemSize())
+	}
+}
+
+func TestTensorValidate(t *testing.T) {
+	valid, err := NewTorchTensorFrom([]float32{1, 2, 3, 4}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer valid.Close()
+	if err := valid.Validate(); err != nil {
+		t.Errorf("valid tensor: %v", err)
+	}
+
+	var short TorchTensor
+	if err := short.AllocateErr([]int64{2, 2}, TorchFloat, 12); err != nil {
+		t.Fatal(err)
+	}
+	defer short.Close()
+
+	var noData TFTensor
+	if err := noData.InitErr([]int64{2}, TfInt32); err != nil {
+		t.Fatal(err)
+	}
+	defer noData.Close()
+
+	var empty TFTensor
+	if err := empty.InitErr([]int64{0, 3}, TfInt32); err != nil {
+		t.Fatal(err)
+	}
+	defer empty.Close()
+	if err := empty.Validate(); err != nil {
+		t.Errorf("tensor without elements: %v", err)
+	}
+
+	/* vAccel may reject these dims itself */
+	var negative TFLiteTensor
+	if err := negative.AllocateErr([]int32{-2, -2}, TfLiteFloat32, 16); err == nil {
+		defer negative.Close()
+	}
+
+	var overflow TFTensor
+	if err := overflow.AllocateErr([]int64{math.MaxInt64 / 2, 4}, TfUint8, 8); err == nil {
+		defer overflow.Close()
+	}
+
+	var unknown TorchTensor
+	if err := unknown.AllocateErr([]int64{1}, TorchDataType(42), 8); err != nil {
+		t.Fatal(err)
+	}
+	defer unknown.Close()
+
+	var int4 TFLiteTensor
+	if err := int4.AllocateErr([]int32{3}, TfLiteInt4, 2); err != nil {
+		t.Fatal(err)
+	}
+	defer int4.Close()
+	if err := int4.Validate(); err != nil {
+		t.Errorf("int4 tensor: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		tensor interface{ Validate() error }
+	}{
+		{"uninitialized", &TFTensor{}},
+		{"short data", &short},
+		{"no data", &noData},
+		{"negative dims", &negative},
+		{"overflow", &overflow},
+		{"unknown type", &unknown},
+	}
+	for _, tt := range tests {
+		if err := tt.tensor.Validate(); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, ErrInvalid)
+		}
+	}
+}
+
+func TestModelRunValidates(t *testing.T) {
+	sess := newTestSession(t)
+	model := newTestLibResource(t)
+
+	var short TorchTensor
+	if err := short.AllocateErr([]int64{4}, TorchFloat, 8); err != nil {
+		t.Fatal(err)
+	}
+	defer short.Close()
+
+	outs := make([]TorchTensor, 1)
+	err := TorchModelRunErr(sess, model, nil, []TorchTensor{short}, &outs)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("TorchModelRunErr: got %v, want %v", err, ErrInvalid)
+	}
+	if ret := TorchModelRun(sess, model, nil, []TorchTensor{short}, &outs); ret != EINVAL {
+		t.Errorf("TorchModelRun: got %d, want %d", ret, EINVAL)
+	}
+	err = TorchModelRunCtx(context.Background(), sess, model, nil, []TorchTensor{short}, &outs)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("TorchModelRunCtx: got %v, want %v", err, ErrInvalid)
+	}
+}
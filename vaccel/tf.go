@@ -5,7 +5,10 @@ package vaccel
 // #include <vaccel/ops/tf.h>
 import "C"
 import (
+	"context"
 	"fmt"
+	"runtime"
+	"slices"
 	"strings"
 	"unsafe"
 )
@@ -40,21 +43,59 @@ const (
 )
 
 type TFBuffer struct {
-	cTFBuf C.struct_vaccel_tf_buffer
+	cTFBuf *C.struct_vaccel_tf_buffer
+	h      *handle
 }
 
 func (b *TFBuffer) Init(data uintptr, size uint) int {
+	cBuf := (*C.struct_vaccel_tf_buffer)(C.calloc(1, C.sizeof_struct_vaccel_tf_buffer))
+	if cBuf == nil {
+		return ENOMEM
+	}
+
 	cData := unsafe.Pointer(data)
 	cSize := C.size_t(size)
-	return int(C.vaccel_tf_buffer_init(&b.cTFBuf, cData, cSize))
+	ret := int(C.vaccel_tf_buffer_init(cBuf, cData, cSize))
+	if ret != OK {
+		C.free(unsafe.Pointer(cBuf))
+		return ret
+	}
+
+	b.cTFBuf = cBuf
+	b.h = newHandle("TFBuffer", func() int {
+		ret := int(C.vaccel_tf_buffer_release(cBuf))
+		if ret == OK {
+			C.free(unsafe.Pointer(cBuf))
+		}
+		return ret
+	})
+
+	return OK
 }
 
 func (b *TFBuffer) Release() int {
-	return int(C.vaccel_tf_buffer_release(&b.cTFBuf))
+	if b.h == nil {
+		return EINVAL
+	}
+	ret := b.h.close()
+	if ret == OK {
+		b.cTFBuf = nil
+	}
+	return ret
+}
+
+// Close releases the buffer. It implements io.Closer and, unlike Release,
+// it is a no-op for a buffer that was never initialized.
+func (b *TFBuffer) Close() error {
+	if b.h == nil {
+		return nil
+	}
+	return NewError("vaccel_tf_buffer_release", b.Release())
 }
 
 func (b *TFBuffer) TakeData() (uintptr, uint) {
-	if b == nil {
+	defer runtime.KeepAlive(b)
+	if b == nil || b.cTFBuf == nil {
 		return 0, 0
 	}
 
@@ -68,22 +109,60 @@ func (b *TFBuffer) TakeData() (uintptr, uint) {
 }
 
 type TFNode struct {
-	cTFNode C.struct_vaccel_tf_node
+	cTFNode *C.struct_vaccel_tf_node
+	h       *handle
 }
 
 func (n *TFNode) Init(name string, id int) int {
+	cNode := (*C.struct_vaccel_tf_node)(C.calloc(1, C.sizeof_struct_vaccel_tf_node))
+	if cNode == nil {
+		return ENOMEM
+	}
+
 	cInt := C.int(id)
 	cStr := C.CString(name)
 	defer C.free(unsafe.Pointer(cStr))
-	return int(C.vaccel_tf_node_init(&n.cTFNode, cStr, cInt))
+	ret := int(C.vaccel_tf_node_init(cNode, cStr, cInt))
+	if ret != OK {
+		C.free(unsafe.Pointer(cNode))
+		return ret
+	}
+
+	n.cTFNode = cNode
+	n.h = newHandle("TFNode", func() int {
+		ret := int(C.vaccel_tf_node_release(cNode))
+		if ret == OK {
+			C.free(unsafe.Pointer(cNode))
+		}
+		return ret
+	})
+
+	return OK
 }
 
 func (n *TFNode) Release() int {
-	return int(C.vaccel_tf_node_release(&n.cTFNode))
+	if n.h == nil {
+		return EINVAL
+	}
+	ret := n.h.close()
+	if ret == OK {
+		n.cTFNode = nil
+	}
+	return ret
+}
+
+// Close releases the node. It implements io.Closer and, unlike Release, it
+// is a no-op for a node that was never initialized.
+func (n *TFNode) Close() error {
+	if n.h == nil {
+		return nil
+	}
+	return NewError("vaccel_tf_node_release", n.Release())
 }
 
 type TFTensor struct {
-	cTFTensor C.struct_vaccel_tf_tensor
+	cTFTensor *C.struct_vaccel_tf_tensor
+	h         *handle
 }
 
 func (t *TFTensor) Init(dims []int64, dtype TFDataType) int {
@@ -103,21 +182,56 @@ func (t *TFTensor) Init(dims []int64, dtype TFDataType) int {
 		*ptr = C.int64_t(dims[i])
 	}
 
-	ret := C.vaccel_tf_tensor_init(
-		&t.cTFTensor,
+	cTensor := (*C.struct_vaccel_tf_tensor)(C.calloc(1, C.sizeof_struct_vaccel_tf_tensor))
+	if cTensor == nil {
+		return ENOMEM
+	}
+
+	ret := int(C.vaccel_tf_tensor_init(
+		cTensor,
 		C.int(len(dims)),
 		cDims,
 		C.enum_vaccel_tf_data_type(dtype),
-	)
+	))
+	if ret != OK {
+		C.free(unsafe.Pointer(cTensor))
+		return ret
+	}
 
-	return int(ret)
+	t.cTFTensor = cTensor
+	t.h = newHandle("TFTensor", func() int {
+		ret := int(C.vaccel_tf_tensor_release(cTensor))
+		if ret == OK {
+			C.free(unsafe.Pointer(cTensor))
+		}
+		return ret
+	})
+
+	return OK
 }
 
 func (t *TFTensor) Release() int {
-	return int(C.vaccel_tf_tensor_release(&t.cTFTensor))
+	if t == nil || t.h == nil {
+		return EINVAL
+	}
+	ret := t.h.close()
+	if ret == OK {
+		t.cTFTensor = nil
+	}
+	return ret
+}
+
+// Close releases the tensor. It implements io.Closer and, unlike Release, it
+// is a no-op for a tensor that was never initialized.
+func (t *TFTensor) Close() error {
+	if t == nil || t.h == nil {
+		return nil
+	}
+	return NewError("vaccel_tf_tensor_release", t.Release())
 }
 
 func (t *TFTensor) Allocate(dims []int64, dtype TFDataType, totalSize uint) int {
+	defer runtime.KeepAlive(t)
 	ret := t.Init(dims, dtype)
 	if ret != OK {
 		return ret
@@ -129,7 +243,7 @@ func (t *TFTensor) Allocate(dims []int64, dtype TFDataType, totalSize uint) int
 
 	t.cTFTensor.data = C.malloc(C.size_t(totalSize))
 	if t.cTFTensor.data == nil {
-		C.vaccel_tf_tensor_release(&t.cTFTensor)
+		t.Release()
 		return ENOMEM
 	}
 
@@ -140,7 +254,8 @@ func (t *TFTensor) Allocate(dims []int64, dtype TFDataType, totalSize uint) int
 }
 
 func (t *TFTensor) SetData(data uintptr, size uint, own bool) int {
-	if t == nil {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTFTensor == nil {
 		return EINVAL
 	}
 
@@ -156,7 +271,8 @@ func (t *TFTensor) SetData(data uintptr, size uint, own bool) int {
 }
 
 func (t *TFTensor) TakeData() (uintptr, uint) {
-	if t == nil {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTFTensor == nil {
 		return 0, 0
 	}
 
@@ -171,35 +287,40 @@ func (t *TFTensor) TakeData() (uintptr, uint) {
 }
 
 func (t *TFTensor) Data() uintptr {
-	if t == nil {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTFTensor == nil {
 		return 0
 	}
 	return uintptr(t.cTFTensor.data)
 }
 
 func (t *TFTensor) Size() int {
-	if t == nil {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTFTensor == nil {
 		return 0
 	}
 	return int(t.cTFTensor.size)
 }
 
 func (t *TFTensor) NrDims() int {
-	if t == nil || t.cTFTensor.dims == nil || t.cTFTensor.nr_dims <= 0 {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTFTensor == nil || t.cTFTensor.dims == nil || t.cTFTensor.nr_dims <= 0 {
 		return -1
 	}
 	return int(t.cTFTensor.nr_dims)
 }
 
 func (t *TFTensor) Type() TFDataType {
-	if t == nil || t.cTFTensor.data_type < 1 {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTFTensor == nil || t.cTFTensor.data_type < 1 {
 		return -1
 	}
 	return TFDataType(t.cTFTensor.data_type)
 }
 
 func (t *TFTensor) Dims() []int64 {
-	if t == nil || t.cTFTensor.dims == nil || t.cTFTensor.nr_dims <= 0 {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTFTensor == nil || t.cTFTensor.dims == nil || t.cTFTensor.nr_dims <= 0 {
 		return nil
 	}
 
@@ -213,14 +334,27 @@ func (t *TFTensor) Dims() []int64 {
 }
 
 func (t *TFTensor) DataPtr() uintptr {
-	if t == nil || t.cTFTensor.data == nil {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTFTensor == nil || t.cTFTensor.data == nil {
 		return 0
 	}
 	return uintptr(t.cTFTensor.data)
 }
 
+// tensorData returns the C memory of the tensor data. The caller must keep
+// t alive while it uses the memory.
+func (t *TFTensor) tensorData() []byte {
+	if t == nil || t.cTFTensor == nil || t.cTFTensor.data == nil {
+		return nil
+	}
+	return unsafe.Slice((*byte)(t.cTFTensor.data), int(t.cTFTensor.size))
+}
+
+// PrintFloat32Data prints the shape and the elements of a float32 tensor
+// to stdout. Use fmt, or String, for tensors of any numeric data type.
 func (t *TFTensor) PrintFloat32Data() {
-	if t == nil || t.cTFTensor.data == nil {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTFTensor == nil || t.cTFTensor.data == nil {
 		fmt.Println("nil tensor")
 		return
 	}
@@ -313,9 +447,14 @@ func TFModelLoad(sess *Session, model *Resource, status *TFStatus) int {
 		return EINVAL
 	}
 
+	defer runtime.KeepAlive(sess)
+	defer runtime.KeepAlive(model)
 	return int(C.vaccel_tf_model_load(sess.cSess, model.cRes, &status.cTFStatus))
 }
 
+// TFModelRun runs model with inTensors and stores its outputs in
+// outTensors. It returns EINVAL without running the model if an input
+// tensor fails TFTensor.Validate.
 func TFModelRun(
 	sess *Session,
 	model *Resource,
@@ -329,6 +468,12 @@ func TFModelRun(
 	if sess == nil || model == nil || inNodes == nil || outNodes == nil || status == nil || outTensors == nil {
 		return EINVAL
 	}
+	if sess.Poisoned() {
+		return EBUSY
+	}
+	if validateTensors("vaccel_tf_model_run", inTensors) != nil {
+		return EINVAL
+	}
 
 	nrInputs := len(inTensors)
 	nrOutputs := len(*outTensors)
@@ -342,30 +487,41 @@ func TFModelRun(
 
 	inTensorSlice := unsafe.Slice((**C.struct_vaccel_tf_tensor)(cInPtr), nrInputs)
 	for i := 0; i < nrInputs; i++ {
-		inTensorSlice[i] = &inTensors[i].cTFTensor
+		inTensorSlice[i] = inTensors[i].cTFTensor
 	}
 
 	outBufSize := C.size_t(nrOutputs) * C.size_t(unsafe.Sizeof(uintptr(0)))
 	cOutPtr := C.malloc(outBufSize)
 	defer C.free(cOutPtr)
 
+	var cRunOptions *C.struct_vaccel_tf_buffer
+	if runOptions != nil {
+		cRunOptions = runOptions.cTFBuf
+		if cRunOptions == nil {
+			/* Uninitialized run options are passed as an empty buffer */
+			cRunOptions = (*C.struct_vaccel_tf_buffer)(C.calloc(1, C.sizeof_struct_vaccel_tf_buffer))
+			defer C.free(unsafe.Pointer(cRunOptions))
+		}
+	}
+
 	ret := int(C.vaccel_tf_model_run(
 		sess.cSess,
 		model.cRes,
-		func() *C.struct_vaccel_tf_buffer {
-			if runOptions != nil {
-				return &runOptions.cTFBuf
-			}
-			return nil
-		}(),
-		&inNodes.cTFNode,
+		cRunOptions,
+		inNodes.cTFNode,
 		(**C.struct_vaccel_tf_tensor)(cInPtr),
 		C.int(nrInputs),
-		&outNodes.cTFNode,
+		outNodes.cTFNode,
 		(**C.struct_vaccel_tf_tensor)(cOutPtr),
 		C.int(nrOutputs),
 		&status.cTFStatus,
 	))
+	runtime.KeepAlive(sess)
+	runtime.KeepAlive(model)
+	runtime.KeepAlive(runOptions)
+	runtime.KeepAlive(inNodes)
+	runtime.KeepAlive(inTensors)
+	runtime.KeepAlive(outNodes)
 	if ret != OK {
 		return ret
 	}
@@ -416,5 +572,147 @@ func TFModelRun(
 
 func TFModelUnload(sess *Session, model *Resource, status *TFStatus) int {
 	err := int(C.vaccel_tf_model_unload(sess.cSess, model.cRes, &status.cTFStatus))
+	runtime.KeepAlive(sess)
+	runtime.KeepAlive(model)
+	return err
+}
+
+// InitErr is like Init but returns an error.
+func (b *TFBuffer) InitErr(data uintptr, size uint) error {
+	return NewError("vaccel_tf_buffer_init", b.Init(data, size))
+}
+
+// ReleaseErr is like Release but returns an error.
+func (b *TFBuffer) ReleaseErr() error {
+	return NewError("vaccel_tf_buffer_release", b.Release())
+}
+
+// InitErr is like Init but returns an error.
+func (n *TFNode) InitErr(name string, id int) error {
+	return NewError("vaccel_tf_node_init", n.Init(name, id))
+}
+
+// ReleaseErr is like Release but returns an error.
+func (n *TFNode) ReleaseErr() error {
+	return NewError("vaccel_tf_node_release", n.Release())
+}
+
+// InitErr is like Init but returns an error.
+func (t *TFTensor) InitErr(dims []int64, dtype TFDataType) error {
+	return NewError("vaccel_tf_tensor_init", t.Init(dims, dtype))
+}
+
+// ReleaseErr is like Release but returns an error.
+func (t *TFTensor) ReleaseErr() error {
+	return NewError("vaccel_tf_tensor_release", t.Release())
+}
+
+// AllocateErr is like Allocate but returns an error.
+func (t *TFTensor) AllocateErr(dims []int64, dtype TFDataType, totalSize uint) error {
+	return NewError("vaccel_tf_tensor_init", t.Allocate(dims, dtype, totalSize))
+}
+
+// SetDataErr is like SetData but returns an error.
+func (t *TFTensor) SetDataErr(data uintptr, size uint, own bool) error {
+	return NewError("TFTensor.SetData", t.SetData(data, size, own))
+}
+
+// InitErr is like Init but returns an error.
+func (s *TFStatus) InitErr(errorCode uint8, message string) error {
+	return NewError("vaccel_tf_status_init", s.Init(errorCode, message))
+}
+
+// ReleaseErr is like Release but returns an error.
+func (s *TFStatus) ReleaseErr() error {
+	return NewError("vaccel_tf_status_release", s.Release())
+}
+
+// TFModelLoadErr is like TFModelLoad but returns an error.
+func TFModelLoadErr(sess *Session, model *Resource, status *TFStatus) error {
+	return NewError("vaccel_tf_model_load", TFModelLoad(sess, model, status))
+}
+
+// TFModelRunErr is like TFModelRun but returns an error. Like TFModelRun, it
+// checks inTensors with Validate first.
+func TFModelRunErr(
+	sess *Session,
+	model *Resource,
+	runOptions *TFBuffer,
+	inNodes *TFNode,
+	inTensors []TFTensor,
+	outNodes *TFNode,
+	outTensors *[]TFTensor,
+	status *TFStatus,
+) error {
+	if err := validateTensors("vaccel_tf_model_run", inTensors); err != nil {
+		return err
+	}
+	return NewError("vaccel_tf_model_run",
+		TFModelRun(sess, model, runOptions, inNodes, inTensors, outNodes, outTensors, status))
+}
+
+// TFModelUnloadErr is like TFModelUnload but returns an error.
+func TFModelUnloadErr(sess *Session, model *Resource, status *TFStatus) error {
+	return NewError("vaccel_tf_model_unload", TFModelUnload(sess, model, status))
+}
+
+// TFModelRunCtx is like TFModelRunErr but returns ctx.Err() as soon as ctx
+// is done. The C call can not be interrupted, so it keeps running in the
+// background and sess is poisoned until it returns. The inputs can be
+// released right away; their C memory is released when the call returns.
+// Outputs of an abandoned call are released; outTensors and status are only
+// updated on completion.
+func TFModelRunCtx(
+	ctx context.Context,
+	sess *Session,
+	model *Resource,
+	runOptions *TFBuffer,
+	inNodes *TFNode,
+	inTensors []TFTensor,
+	outNodes *TFNode,
+	outTensors *[]TFTensor,
+	status *TFStatus,
+) error {
+	if sess == nil || model == nil || inNodes == nil || outNodes == nil || outTensors == nil || status == nil {
+		return NewError("vaccel_tf_model_run", EINVAL)
+	}
+	if err := validateTensors("vaccel_tf_model_run", inTensors); err != nil {
+		return err
+	}
+
+	/* the call runs on copies of the inputs, with their C memory pinned */
+	m, in, out := model.ref(), *inNodes, *outNodes
+	ins := slices.Clone(inTensors)
+	pins := []*handle{m.h, in.h, out.h}
+	for i := range ins {
+		pins = append(pins, ins[i].h)
+	}
+	var opts *TFBuffer
+	if runOptions != nil {
+		o := *runOptions
+		opts = &o
+		pins = append(pins, o.h)
+	}
+
+	outs := make([]TFTensor, len(*outTensors))
+	var st TFStatus
+	release := func() {
+		for i := range outs {
+			if outs[i].NrDims() > 0 {
+				outs[i].Release()
+			}
+		}
+		st.Release()
+	}
+
+	completed, err := sess.callCtx(ctx, "vaccel_tf_model_run", func() int {
+		return TFModelRun(sess, m, opts, &in, ins, &out, &outs, &st)
+	}, release, pins...)
+	if !completed {
+		return err
+	}
+
+	copy(*outTensors, outs)
+	*status = st
 	return err
 }
@@ -5,7 +5,10 @@ package vaccel
 // #include <vaccel/ops/tflite.h>
 import "C"
 import (
+	"context"
 	"fmt"
+	"runtime"
+	"slices"
 	"unsafe"
 )
 
@@ -34,7 +37,8 @@ const (
 )
 
 type TFLiteTensor struct {
-	cTFLiteTensor C.struct_vaccel_tflite_tensor
+	cTFLiteTensor *C.struct_vaccel_tflite_tensor
+	h             *handle
 }
 
 func (t *TFLiteTensor) Init(dims []int32, dtype TFLiteDataType) int {
@@ -54,21 +58,56 @@ func (t *TFLiteTensor) Init(dims []int32, dtype TFLiteDataType) int {
 		*ptr = C.int32_t(dims[i])
 	}
 
-	ret := C.vaccel_tflite_tensor_init(
-		&t.cTFLiteTensor,
+	cTensor := (*C.struct_vaccel_tflite_tensor)(C.calloc(1, C.sizeof_struct_vaccel_tflite_tensor))
+	if cTensor == nil {
+		return ENOMEM
+	}
+
+	ret := int(C.vaccel_tflite_tensor_init(
+		cTensor,
 		C.int(len(dims)),
 		cDims,
 		C.enum_vaccel_tflite_data_type(dtype),
-	)
+	))
+	if ret != OK {
+		C.free(unsafe.Pointer(cTensor))
+		return ret
+	}
+
+	t.cTFLiteTensor = cTensor
+	t.h = newHandle("TFLiteTensor", func() int {
+		ret := int(C.vaccel_tflite_tensor_release(cTensor))
+		if ret == OK {
+			C.free(unsafe.Pointer(cTensor))
+		}
+		return ret
+	})
 
-	return int(ret)
+	return OK
 }
 
 func (t *TFLiteTensor) Release() int {
-	return int(C.vaccel_tflite_tensor_release(&t.cTFLiteTensor))
+	if t == nil || t.h == nil {
+		return EINVAL
+	}
+	ret := t.h.close()
+	if ret == OK {
+		t.cTFLiteTensor = nil
+	}
+	return ret
+}
+
+// Close releases the tensor. It implements io.Closer and, unlike Release, it
+// is a no-op for a tensor that was never initialized.
+func (t *TFLiteTensor) Close() error {
+	if t == nil || t.h == nil {
+		return nil
+	}
+	return NewError("vaccel_tflite_tensor_release", t.Release())
 }
 
 func (t *TFLiteTensor) Allocate(dims []int32, dtype TFLiteDataType, totalSize uint) int {
+	defer runtime.KeepAlive(t)
 	ret := t.Init(dims, dtype)
 	if ret != OK {
 		return ret
@@ -80,7 +119,7 @@ func (t *TFLiteTensor) Allocate(dims []int32, dtype TFLiteDataType, totalSize ui
 
 	t.cTFLiteTensor.data = C.malloc(C.size_t(totalSize))
 	if t.cTFLiteTensor.data == nil {
-		C.vaccel_tflite_tensor_release(&t.cTFLiteTensor)
+		t.Release()
 		return ENOMEM
 	}
 
@@ -91,7 +130,8 @@ func (t *TFLiteTensor) Allocate(dims []int32, dtype TFLiteDataType, totalSize ui
 }
 
 func (t *TFLiteTensor) SetData(data uintptr, size uint, own bool) int {
-	if t == nil {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTFLiteTensor == nil {
 		return EINVAL
 	}
 
@@ -107,7 +147,8 @@ func (t *TFLiteTensor) SetData(data uintptr, size uint, own bool) int {
 }
 
 func (t *TFLiteTensor) TakeData() (uintptr, uint) {
-	if t == nil {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTFLiteTensor == nil {
 		return 0, 0
 	}
 
@@ -122,35 +163,40 @@ func (t *TFLiteTensor) TakeData() (uintptr, uint) {
 }
 
 func (t *TFLiteTensor) Data() uintptr {
-	if t == nil {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTFLiteTensor == nil {
 		return 0
 	}
 	return uintptr(t.cTFLiteTensor.data)
 }
 
 func (t *TFLiteTensor) Size() int {
-	if t == nil {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTFLiteTensor == nil {
 		return 0
 	}
 	return int(t.cTFLiteTensor.size)
 }
 
 func (t *TFLiteTensor) NrDims() int {
-	if t == nil || t.cTFLiteTensor.dims == nil || t.cTFLiteTensor.nr_dims <= 0 {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTFLiteTensor == nil || t.cTFLiteTensor.dims == nil || t.cTFLiteTensor.nr_dims <= 0 {
 		return -1
 	}
 	return int(t.cTFLiteTensor.nr_dims)
 }
 
 func (t *TFLiteTensor) Type() TFLiteDataType {
-	if t == nil || t.cTFLiteTensor.data_type < 1 {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTFLiteTensor == nil || t.cTFLiteTensor.data_type < 1 {
 		return -1
 	}
 	return TFLiteDataType(t.cTFLiteTensor.data_type)
 }
 
 func (t *TFLiteTensor) Dims() []int32 {
-	if t == nil || t.cTFLiteTensor.dims == nil || t.cTFLiteTensor.nr_dims <= 0 {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTFLiteTensor == nil || t.cTFLiteTensor.dims == nil || t.cTFLiteTensor.nr_dims <= 0 {
 		return nil
 	}
 
@@ -164,14 +210,27 @@ func (t *TFLiteTensor) Dims() []int32 {
 }
 
 func (t *TFLiteTensor) DataPtr() uintptr {
-	if t == nil || t.cTFLiteTensor.data == nil {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTFLiteTensor == nil || t.cTFLiteTensor.data == nil {
 		return 0
 	}
 	return uintptr(t.cTFLiteTensor.data)
 }
 
+// tensorData returns the C memory of the tensor data. The caller must keep
+// t alive while it uses the memory.
+func (t *TFLiteTensor) tensorData() []byte {
+	if t == nil || t.cTFLiteTensor == nil || t.cTFLiteTensor.data == nil {
+		return nil
+	}
+	return unsafe.Slice((*byte)(t.cTFLiteTensor.data), int(t.cTFLiteTensor.size))
+}
+
+// PrintFloat32Data prints the shape and the elements of a float32 tensor
+// to stdout. Use fmt, or String, for tensors of any numeric data type.
 func (t *TFLiteTensor) PrintFloat32Data() {
-	if t == nil || t.cTFLiteTensor.data == nil {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTFLiteTensor == nil || t.cTFLiteTensor.data == nil {
 		fmt.Println("nil tensor")
 		return
 	}
@@ -228,9 +287,14 @@ func TFLiteModelLoad(sess *Session, model *Resource) int {
 		return EINVAL
 	}
 
+	defer runtime.KeepAlive(sess)
+	defer runtime.KeepAlive(model)
 	return int(C.vaccel_tflite_model_load(sess.cSess, model.cRes))
 }
 
+// TFLiteModelRun runs model with inTensors and stores its outputs in
+// outTensors. It returns EINVAL without running the model if an input
+// tensor fails TFLiteTensor.Validate.
 func TFLiteModelRun(
 	sess *Session,
 	model *Resource,
@@ -240,6 +304,12 @@ func TFLiteModelRun(
 	if sess == nil || model == nil || outTensors == nil {
 		return EINVAL, 0
 	}
+	if sess.Poisoned() {
+		return EBUSY, 0
+	}
+	if validateTensors("vaccel_tflite_model_run", inTensors) != nil {
+		return EINVAL, 0
+	}
 
 	nrInputs := len(inTensors)
 	nrOutputs := len(*outTensors)
@@ -253,7 +323,7 @@ func TFLiteModelRun(
 
 	inTensorSlice := unsafe.Slice((**C.struct_vaccel_tflite_tensor)(cInPtr), nrInputs)
 	for i := 0; i < nrInputs; i++ {
-		inTensorSlice[i] = &inTensors[i].cTFLiteTensor
+		inTensorSlice[i] = inTensors[i].cTFLiteTensor
 	}
 
 	outBufSize := C.size_t(nrOutputs) * C.size_t(unsafe.Sizeof(uintptr(0)))
@@ -270,6 +340,9 @@ func TFLiteModelRun(
 		C.int(nrOutputs),
 		&cStatus,
 	))
+	runtime.KeepAlive(sess)
+	runtime.KeepAlive(model)
+	runtime.KeepAlive(inTensors)
 	if ret != OK {
 		return ret, uint8(cStatus)
 	}
@@ -320,5 +393,104 @@ func TFLiteModelRun(
 
 func TFLiteModelUnload(sess *Session, model *Resource) int {
 	err := int(C.vaccel_tflite_model_unload(sess.cSess, model.cRes))
+	runtime.KeepAlive(sess)
+	runtime.KeepAlive(model)
 	return err
 }
+
+// InitErr is like Init but returns an error.
+func (t *TFLiteTensor) InitErr(dims []int32, dtype TFLiteDataType) error {
+	return NewError("vaccel_tflite_tensor_init", t.Init(dims, dtype))
+}
+
+// ReleaseErr is like Release but returns an error.
+func (t *TFLiteTensor) ReleaseErr() error {
+	return NewError("vaccel_tflite_tensor_release", t.Release())
+}
+
+// AllocateErr is like Allocate but returns an error.
+func (t *TFLiteTensor) AllocateErr(dims []int32, dtype TFLiteDataType, totalSize uint) error {
+	return NewError("vaccel_tflite_tensor_init", t.Allocate(dims, dtype, totalSize))
+}
+
+// SetDataErr is like SetData but returns an error.
+func (t *TFLiteTensor) SetDataErr(data uintptr, size uint, own bool) error {
+	return NewError("TFLiteTensor.SetData", t.SetData(data, size, own))
+}
+
+// TFLiteModelLoadErr is like TFLiteModelLoad but returns an error.
+func TFLiteModelLoadErr(sess *Session, model *Resource) error {
+	return NewError("vaccel_tflite_model_load", TFLiteModelLoad(sess, model))
+}
+
+// TFLiteModelRunErr is like TFLiteModelRun but returns an error. The TFLite
+// status is returned along with the error. Like TFLiteModelRun, it checks
+// inTensors with Validate first.
+func TFLiteModelRunErr(
+	sess *Session,
+	model *Resource,
+	inTensors []TFLiteTensor,
+	outTensors *[]TFLiteTensor,
+) (uint8, error) {
+	if err := validateTensors("vaccel_tflite_model_run", inTensors); err != nil {
+		return 0, err
+	}
+	ret, status := TFLiteModelRun(sess, model, inTensors, outTensors)
+	return status, NewError("vaccel_tflite_model_run", ret)
+}
+
+// TFLiteModelUnloadErr is like TFLiteModelUnload but returns an error.
+func TFLiteModelUnloadErr(sess *Session, model *Resource) error {
+	return NewError("vaccel_tflite_model_unload", TFLiteModelUnload(sess, model))
+}
+
+// TFLiteModelRunCtx is like TFLiteModelRunErr but returns ctx.Err() as soon
+// as ctx is done. The C call can not be interrupted, so it keeps running in
+// the background and sess is poisoned until it returns. The inputs can be
+// released right away; their C memory is released when the call returns.
+// Outputs of an abandoned call are released; outTensors is only updated on
+// completion.
+func TFLiteModelRunCtx(
+	ctx context.Context,
+	sess *Session,
+	model *Resource,
+	inTensors []TFLiteTensor,
+	outTensors *[]TFLiteTensor,
+) (uint8, error) {
+	if sess == nil || model == nil || outTensors == nil {
+		return 0, NewError("vaccel_tflite_model_run", EINVAL)
+	}
+	if err := validateTensors("vaccel_tflite_model_run", inTensors); err != nil {
+		return 0, err
+	}
+
+	/* the call runs on copies of the inputs, with their C memory pinned */
+	m := model.ref()
+	ins := slices.Clone(inTensors)
+	pins := []*handle{m.h}
+	for i := range ins {
+		pins = append(pins, ins[i].h)
+	}
+
+	outs := make([]TFLiteTensor, len(*outTensors))
+	var status uint8
+	release := func() {
+		for i := range outs {
+			if outs[i].NrDims() > 0 {
+				outs[i].Release()
+			}
+		}
+	}
+
+	completed, err := sess.callCtx(ctx, "vaccel_tflite_model_run", func() int {
+		var ret int
+		ret, status = TFLiteModelRun(sess, m, ins, &outs)
+		return ret
+	}, release, pins...)
+	if !completed {
+		return 0, err
+	}
+
+	copy(*outTensors, outs)
+	return status, err
+}
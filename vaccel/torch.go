@@ -5,7 +5,12 @@ package vaccel
 // #include <vaccel/ops/torch.h>
 import "C"
 import (
+	"context"
 	"fmt"
+	"math"
+	"runtime"
+	"slices"
+	"sync"
 	"unsafe"
 )
 
@@ -48,6 +53,7 @@ func (b *TorchBuffer) TakeData() string {
 
 type TorchTensor struct {
 	cTorchTensor *C.struct_vaccel_torch_tensor
+	h            *handle
 }
 
 func (t *TorchTensor) Init(dims []int64, dtype TorchDataType) int {
@@ -73,15 +79,40 @@ func (t *TorchTensor) Init(dims []int64, dtype TorchDataType) int {
 		cDims,
 		C.enum_vaccel_torch_data_type(dtype),
 	)
+	if ret != C.int(OK) {
+		return int(ret)
+	}
+
+	cTensor := t.cTorchTensor
+	t.h = newHandle("TorchTensor", func() int {
+		return int(C.vaccel_torch_tensor_delete(cTensor))
+	})
 
-	return int(ret)
+	return OK
 }
 
 func (t *TorchTensor) Release() int {
-	return int(C.vaccel_torch_tensor_delete(t.cTorchTensor))
+	if t == nil || t.h == nil {
+		return EINVAL
+	}
+	ret := t.h.close()
+	if ret == OK {
+		t.cTorchTensor = nil
+	}
+	return ret
+}
+
+// Close releases the tensor. It implements io.Closer and, unlike Release, it
+// is a no-op for a tensor that was never initialized.
+func (t *TorchTensor) Close() error {
+	if t == nil || t.h == nil {
+		return nil
+	}
+	return NewError("vaccel_torch_tensor_delete", t.Release())
 }
 
 func (t *TorchTensor) Allocate(dims []int64, dtype TorchDataType, totalSize uint) int {
+	defer runtime.KeepAlive(t)
 	ret := t.Init(dims, dtype)
 	if ret != OK {
 		return ret
@@ -93,7 +124,7 @@ func (t *TorchTensor) Allocate(dims []int64, dtype TorchDataType, totalSize uint
 
 	t.cTorchTensor.data = C.malloc(C.size_t(totalSize))
 	if t.cTorchTensor.data == nil {
-		C.vaccel_torch_tensor_delete(t.cTorchTensor)
+		t.Release()
 		return ENOMEM
 	}
 
@@ -104,7 +135,8 @@ func (t *TorchTensor) Allocate(dims []int64, dtype TorchDataType, totalSize uint
 }
 
 func (t *TorchTensor) SetData(data uintptr, size uint, own bool) int {
-	if t == nil {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTorchTensor == nil {
 		return EINVAL
 	}
 
@@ -120,7 +152,8 @@ func (t *TorchTensor) SetData(data uintptr, size uint, own bool) int {
 }
 
 func (t *TorchTensor) TakeData() (uintptr, uint) {
-	if t == nil {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTorchTensor == nil {
 		return 0, 0
 	}
 
@@ -135,35 +168,40 @@ func (t *TorchTensor) TakeData() (uintptr, uint) {
 }
 
 func (t *TorchTensor) Data() uintptr {
-	if t == nil {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTorchTensor == nil {
 		return 0
 	}
 	return uintptr(t.cTorchTensor.data)
 }
 
 func (t *TorchTensor) Size() int {
-	if t == nil {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTorchTensor == nil {
 		return 0
 	}
 	return int(t.cTorchTensor.size)
 }
 
 func (t *TorchTensor) NrDims() int {
-	if t == nil || t.cTorchTensor.dims == nil || t.cTorchTensor.nr_dims <= 0 {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTorchTensor == nil || t.cTorchTensor.dims == nil || t.cTorchTensor.nr_dims <= 0 {
 		return -1
 	}
 	return int(t.cTorchTensor.nr_dims)
 }
 
 func (t *TorchTensor) Type() TorchDataType {
-	if t == nil || t.cTorchTensor.data_type < 1 {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTorchTensor == nil || t.cTorchTensor.data_type < 1 {
 		return -1
 	}
 	return TorchDataType(t.cTorchTensor.data_type)
 }
 
 func (t *TorchTensor) Dims() []int64 {
-	if t == nil || t.cTorchTensor.dims == nil || t.cTorchTensor.nr_dims <= 0 {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTorchTensor == nil || t.cTorchTensor.dims == nil || t.cTorchTensor.nr_dims <= 0 {
 		return nil
 	}
 
@@ -177,20 +215,35 @@ func (t *TorchTensor) Dims() []int64 {
 }
 
 func (t *TorchTensor) DataPtr() uintptr {
-	if t == nil || t.cTorchTensor.data == nil {
+	defer runtime.KeepAlive(t)
+	if t == nil || t.cTorchTensor == nil || t.cTorchTensor.data == nil {
 		return 0
 	}
 	return uintptr(t.cTorchTensor.data)
 }
 
+// tensorData returns the C memory of the tensor data. The caller must keep
+// t alive while it uses the memory.
+func (t *TorchTensor) tensorData() []byte {
+	if t == nil || t.cTorchTensor == nil || t.cTorchTensor.data == nil {
+		return nil
+	}
+	return unsafe.Slice((*byte)(t.cTorchTensor.data), int(t.cTorchTensor.size))
+}
+
 func TorchModelLoad(sess *Session, model *Resource) int {
 	if sess == nil || model == nil {
 		return EINVAL
 	}
 
+	defer runtime.KeepAlive(sess)
+	defer runtime.KeepAlive(model)
 	return int(C.vaccel_torch_model_load(sess.cSess, model.cRes))
 }
 
+// TorchModelRun runs model with inTensors and stores its outputs in
+// outTensors. It returns EINVAL without running the model if an input
+// tensor fails TorchTensor.Validate.
 func TorchModelRun(
 	sess *Session,
 	model *Resource,
@@ -201,6 +254,12 @@ func TorchModelRun(
 	if sess == nil || model == nil || outTensors == nil {
 		return EINVAL
 	}
+	if sess.Poisoned() {
+		return EBUSY
+	}
+	if validateTensors("vaccel_torch_model_run", inTensors) != nil {
+		return EINVAL
+	}
 
 	nrInputs := len(inTensors)
 	nrOutputs := len(*outTensors)
@@ -237,6 +296,9 @@ func TorchModelRun(
 		(**C.struct_vaccel_torch_tensor)(cOutPtr),
 		C.int(nrOutputs),
 	))
+	runtime.KeepAlive(sess)
+	runtime.KeepAlive(model)
+	runtime.KeepAlive(inTensors)
 	if ret != OK {
 		return ret
 	}
@@ -284,3 +346,222 @@ func TorchModelRun(
 
 	return OK
 }
+
+// InitErr is like Init but returns an error.
+func (b *TorchBuffer) InitErr(data string) error {
+	return NewError("vaccel_torch_buffer_init", b.Init(data))
+}
+
+// ReleaseErr is like Release but returns an error.
+func (b *TorchBuffer) ReleaseErr() error {
+	return NewError("vaccel_torch_buffer_release", b.Release())
+}
+
+// InitErr is like Init but returns an error.
+func (t *TorchTensor) InitErr(dims []int64, dtype TorchDataType) error {
+	return NewError("vaccel_torch_tensor_new", t.Init(dims, dtype))
+}
+
+// ReleaseErr is like Release but returns an error.
+func (t *TorchTensor) ReleaseErr() error {
+	return NewError("vaccel_torch_tensor_delete", t.Release())
+}
+
+// AllocateErr is like Allocate but returns an error.
+func (t *TorchTensor) AllocateErr(dims []int64, dtype TorchDataType, totalSize uint) error {
+	return NewError("vaccel_torch_tensor_new", t.Allocate(dims, dtype, totalSize))
+}
+
+// SetDataErr is like SetData but returns an error.
+func (t *TorchTensor) SetDataErr(data uintptr, size uint, own bool) error {
+	return NewError("TorchTensor.SetData", t.SetData(data, size, own))
+}
+
+// TorchModelLoadErr is like TorchModelLoad but returns an error.
+func TorchModelLoadErr(sess *Session, model *Resource) error {
+	return NewError("vaccel_torch_model_load", TorchModelLoad(sess, model))
+}
+
+// TorchModelRunErr is like TorchModelRun but returns an error. Like
+// TorchModelRun, it checks inTensors with Validate first.
+func TorchModelRunErr(
+	sess *Session,
+	model *Resource,
+	buffer *TorchBuffer,
+	inTensors []TorchTensor,
+	outTensors *[]TorchTensor,
+) error {
+	if err := validateTensors("vaccel_torch_model_run", inTensors); err != nil {
+		return err
+	}
+	return NewError("vaccel_torch_model_run",
+		TorchModelRun(sess, model, buffer, inTensors, outTensors))
+}
+
+// TorchModelRunCtx is like TorchModelRunErr but returns ctx.Err() as soon as
+// ctx is done. The C call can not be interrupted, so it keeps running in the
+// background and sess is poisoned until it returns. The inputs can be
+// released right away; their C memory is released when the call returns.
+// Outputs of an abandoned call are released; outTensors is only updated on
+// completion.
+func TorchModelRunCtx(
+	ctx context.Context,
+	sess *Session,
+	model *Resource,
+	buffer *TorchBuffer,
+	inTensors []TorchTensor,
+	outTensors *[]TorchTensor,
+) error {
+	if sess == nil || model == nil || outTensors == nil {
+		return NewError("vaccel_torch_model_run", EINVAL)
+	}
+	if err := validateTensors("vaccel_torch_model_run", inTensors); err != nil {
+		return err
+	}
+
+	/* the call runs on copies of the inputs, with their C memory pinned */
+	m := model.ref()
+	ins := slices.Clone(inTensors)
+	pins := []*handle{m.h}
+	for i := range ins {
+		pins = append(pins, ins[i].h)
+	}
+	/* buffers are not reference counted, so the call gets its own */
+	var buf *TorchBuffer
+	if buffer != nil && buffer.cTorchBuffer.data != nil {
+		buf = &TorchBuffer{}
+		data := C.GoStringN(buffer.cTorchBuffer.data, C.int(buffer.cTorchBuffer.size))
+		if ret := buf.Init(data); ret != OK {
+			return NewError("vaccel_torch_buffer_init", ret)
+		}
+	}
+	releaseBuf := sync.OnceFunc(func() {
+		if buf != nil {
+			buf.Release()
+		}
+	})
+
+	outs := make([]TorchTensor, len(*outTensors))
+	release := func() {
+		releaseBuf()
+		for i := range outs {
+			if outs[i].cTorchTensor != nil {
+				outs[i].Release()
+			}
+		}
+	}
+
+	completed, err := sess.callCtx(ctx, "vaccel_torch_model_run", func() int {
+		defer releaseBuf()
+		return TorchModelRun(sess, m, buf, ins, &outs)
+	}, release, pins...)
+	if !completed {
+		return err
+	}
+
+	copy(*outTensors, outs)
+	return err
+}
+
+// TorchSgemm computes A*B + C through the Torch plugin, where A is an m x k,
+// B a k x n and C an m x n TorchFloat tensor, and returns the m x n result.
+// It fails with EBACKEND if the plugin reports success without producing a
+// result, e.g. the noop plugin.
+func TorchSgemm(sess *Session, a, b, c *TorchTensor, m, n, k int) (*TorchTensor, error) {
+	if sess == nil || sess.cSess == nil {
+		return nil, NewError("vaccel_torch_sgemm", EINVAL)
+	}
+	if m <= 0 || n <= 0 || k <= 0 || m > math.MaxInt32 || n > math.MaxInt32 || k > math.MaxInt32 {
+		return nil, &Error{Code: EINVAL, Op: "vaccel_torch_sgemm",
+			Msg: fmt.Sprintf("invalid dimensions m=%d n=%d k=%d", m, n, k)}
+	}
+	if err := checkTorchMatrix("A", a, m, k); err != nil {
+		return nil, err
+	}
+	if err := checkTorchMatrix("B", b, k, n); err != nil {
+		return nil, err
+	}
+	if err := checkTorchMatrix("C", c, m, n); err != nil {
+		return nil, err
+	}
+
+	/* one C pointer each, so that the out pointer stays nil if not set */
+	cPtrs := (**C.struct_vaccel_torch_tensor)(C.calloc(4, C.size_t(unsafe.Sizeof(uintptr(0)))))
+	if cPtrs == nil {
+		return nil, NewError("vaccel_torch_sgemm", ENOMEM)
+	}
+	defer C.free(unsafe.Pointer(cPtrs))
+
+	ptrs := unsafe.Slice(cPtrs, 4)
+	ptrs[0], ptrs[1], ptrs[2] = a.cTorchTensor, b.cTorchTensor, c.cTorchTensor
+
+	ret := int(C.vaccel_torch_sgemm(sess.cSess, &ptrs[0], &ptrs[1], &ptrs[2],
+		C.int(m), C.int(n), C.int(k), &ptrs[3]))
+	runtime.KeepAlive(sess)
+	runtime.KeepAlive(a)
+	runtime.KeepAlive(b)
+	runtime.KeepAlive(c)
+	if ret != OK {
+		return nil, NewError("vaccel_torch_sgemm", ret)
+	}
+	if ptrs[3] == nil {
+		return nil, &Error{Code: EBACKEND, Op: "vaccel_torch_sgemm", Msg: "plugin did not return a result"}
+	}
+
+	out, err := torchTensorFromC(ptrs[3])
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// checkTorchMatrix checks that t is a rows x cols TorchFloat tensor with
+// data.
+func checkTorchMatrix(name string, t *TorchTensor, rows, cols int) error {
+	if t == nil || t.cTorchTensor == nil {
+		return &Error{Code: EINVAL, Op: "vaccel_torch_sgemm", Msg: name + " is not initialized"}
+	}
+	if t.Type() != TorchFloat {
+		return &Error{Code: EINVAL, Op: "vaccel_torch_sgemm",
+			Msg: fmt.Sprintf("%s has data type %d, want TorchFloat", name, t.Type())}
+	}
+	dims := t.Dims()
+	if !slices.Equal(dims, []int64{int64(rows), int64(cols)}) {
+		return &Error{Code: EINVAL, Op: "vaccel_torch_sgemm",
+			Msg: fmt.Sprintf("%s has dims %v, want [%d %d]", name, dims, rows, cols)}
+	}
+	if t.DataPtr() == 0 || t.Size()/int(unsafe.Sizeof(float32(0))) < rows*cols {
+		return &Error{Code: EINVAL, Op: "vaccel_torch_sgemm",
+			Msg: fmt.Sprintf("%s has %d bytes of data, want %d float32 values", name, t.Size(), rows*cols)}
+	}
+	return nil
+}
+
+// torchTensorFromC moves the C tensor src, allocated by vAccel, to a new
+// TorchTensor and deletes src.
+func torchTensorFromC(src *C.struct_vaccel_torch_tensor) (*TorchTensor, error) {
+	dtype := TorchDataType(src.data_type)
+	nrDims := int(src.nr_dims)
+	dims := make([]int64, nrDims)
+	copy(dims, unsafe.Slice((*int64)(unsafe.Pointer(src.dims)), nrDims))
+
+	var data unsafe.Pointer
+	var size C.size_t
+	if ret := int(C.vaccel_torch_tensor_take_data(src, &data, &size)); ret != OK {
+		C.vaccel_torch_tensor_delete(src)
+		return nil, NewError("vaccel_torch_tensor_take_data", ret)
+	}
+	if ret := int(C.vaccel_torch_tensor_delete(src)); ret != OK {
+		C.free(data)
+		return nil, NewError("vaccel_torch_tensor_delete", ret)
+	}
+
+	t := &TorchTensor{}
+	if err := t.InitErr(dims, dtype); err != nil {
+		C.free(data)
+		return nil, err
+	}
+	t.SetData(uintptr(data), uint(size), true)
+
+	return t, nil
+}
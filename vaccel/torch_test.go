@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"errors"
+	"testing"
+)
+
+// newTorchFloatTensor returns a TorchFloat tensor with a copy of data.
+func newTorchFloatTensor(t *testing.T, data []float32, dims ...int64) *TorchTensor {
+	t.Helper()
+
+	tensor, err := NewTorchTensorFrom(data, dims...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tensor.Close() })
+	return tensor
+}
+
+func TestTorchSgemmInvalid(t *testing.T) {
+	sess := newTestSession(t)
+
+	a := newTorchFloatTensor(t, make([]float32, 6), 2, 3)
+	b := newTorchFloatTensor(t, make([]float32, 6), 3, 2)
+	c := newTorchFloatTensor(t, make([]float32, 4), 2, 2)
+	flat := newTorchFloatTensor(t, make([]float32, 6), 6)
+
+	var ints TorchTensor
+	if err := ints.AllocateErr([]int64{2, 3}, TorchInt, 24); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ints.Close() })
+
+	var empty TorchTensor
+	if err := empty.InitErr([]int64{2, 3}, TorchFloat); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { empty.Close() })
+
+	tests := []struct {
+		name    string
+		a, b, c *TorchTensor
+		m, n, k int
+	}{
+		{"zero dims", a, b, c, 0, 2, 3},
+		{"nil tensor", nil, b, c, 2, 2, 3},
+		{"uninitialized", &TorchTensor{}, b, c, 2, 2, 3},
+		{"dtype", &ints, b, c, 2, 2, 3},
+		{"shape", a, b, c, 2, 2, 2},
+		{"rank", flat, b, c, 2, 2, 3},
+		{"no data", &empty, b, c, 2, 2, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := TorchSgemm(sess, tt.a, tt.b, tt.c, tt.m, tt.n, tt.k)
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("got %v, want %v", err, ErrInvalid)
+			}
+		})
+	}
+}
+
+func TestTorchSgemm(t *testing.T) {
+	sess := newTestSession(t)
+
+	aData := []float32{1, 2, 3, 4, 5, 6}
+	bData := []float32{7, 8, 9, 10, 11, 12}
+	cData := []float32{1, 2, 3, 4}
+	want := append([]float32(nil), cData...)
+	sgemmRef(2, 2, 3, 1, aData, 3, bData, 2, 1, want, 2)
+
+	a := newTorchFloatTensor(t, aData, 2, 3)
+	b := newTorchFloatTensor(t, bData, 3, 2)
+	c := newTorchFloatTensor(t, cData, 2, 2)
+
+	out, err := TorchSgemm(sess, a, b, c, 2, 2, 3)
+	skipIfNotSupported(t, err)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	if out.Type() != TorchFloat || out.NrDims() != 2 {
+		t.Fatalf("got result of type %d with %d dims, want 2-D TorchFloat", out.Type(), out.NrDims())
+	}
+	got, err := AsSlice[float32](out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalFloat32s(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
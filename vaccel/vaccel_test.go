@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package vaccel
+
+import (
+	"errors"
+	"testing"
+)
+
+// newTestSession returns a new session that is closed when the test ends,
+// or skips the test if no session can be created, e.g. because no plugin
+// is loaded.
+func newTestSession(t *testing.T) *Session {
+	t.Helper()
+
+	sess, err := NewSession()
+	if err != nil {
+		t.Skipf("can not create session: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := sess.Close(); err != nil {
+			t.Errorf("Session.Close: %v", err)
+		}
+	})
+	return sess
+}
+
+// skipIfNotSupported skips the test if err reports that the loaded plugins
+// do not implement an operation.
+func skipIfNotSupported(t *testing.T, err error) {
+	t.Helper()
+
+	if errors.Is(err, ErrNotSupported) {
+		t.Skipf("operation not supported: %v", err)
+	}
+}